@@ -1,14 +1,32 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/smartcat999/container-ui/proxy"
-	"log"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/distribution/distribution/v3/configuration"
+	"github.com/docker/go-metrics"
+	"github.com/sirupsen/logrus"
 )
 
+// 代理监听地址
+const listenAddr = "127.0.0.1:5000"
+
 func main() {
+	logLevel := getEnvOrDefault("LOG_LEVEL", "info")
+	logFormatter := getEnvOrDefault("LOG_FORMAT", "json")
+	// registry 初始化前的日志也使用结构化格式，初始化时会按同样的配置重新设置
+	if logFormatter != "text" {
+		logrus.SetFormatter(&logrus.JSONFormatter{TimestampFormat: time.RFC3339Nano})
+	}
+
 	// otlp/console/none
 	os.Setenv("OTEL_TRACES_EXPORTER", "console")
 	proxyCfg := &configuration.Proxy{
@@ -18,15 +36,129 @@ func main() {
 		Exec:      nil,
 		TTL:       nil,
 	}
-	registry, err := proxy.SetUpRegistry(nil, "127.0.0.1:5000", proxyCfg)
+	// 缓存过期时间，未设置时使用 registry 默认的 7 天，设置为 0 表示永不过期
+	if os.Getenv("REGISTRY_PROXY_TTL") != "" {
+		ttl := getEnvDurationOrDefault("REGISTRY_PROXY_TTL", 7*24*time.Hour)
+		proxyCfg.TTL = &ttl
+	}
+	limits, err := loadLimits()
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	opts := &proxy.Options{
+		DrainTimeout: getEnvDurationOrDefault("REGISTRY_DRAIN_TIMEOUT", proxy.DefaultDrainTimeout),
+		LogLevel:     logLevel,
+		LogFormatter: logFormatter,
+		Limits:       limits,
+	}
+	registry, err := proxy.SetUpRegistry(nil, listenAddr, proxyCfg, opts)
 	if err != nil {
-		log.Fatal(err)
+		logrus.Fatal(err)
 	}
 
-	log.Printf("Starting registry proxy on :5000")
+	refresher, err := loadTagRefresher()
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	refreshCtx, stopRefresh := context.WithCancel(context.Background())
+	defer stopRefresh()
+	if refresher != nil {
+		go refresher.Run(refreshCtx)
+	}
+
+	// 指标（包括排队中的上游请求数）和 tag 刷新管理接口通过独立端口暴露
+	if adminAddr := getEnvOrDefault("REGISTRY_METRICS_ADDR", ""); adminAddr != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", metrics.Handler())
+			if refresher != nil {
+				mux.Handle("/admin/", refresher.Handler())
+			}
+			logrus.WithField("addr", adminAddr).Info("serving admin api")
+			if err := http.ListenAndServe(adminAddr, mux); err != nil {
+				logrus.WithError(err).Error("admin server stopped")
+			}
+		}()
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"addr":        listenAddr,
+		"remote_url":  proxyCfg.RemoteURL,
+		"remote_auth": proxyCfg.Username != "",
+	}).Info("starting registry proxy")
+	// 收到 SIGTERM/SIGINT 后 registry 会停止监听并等待进行中的请求，超时后返回 DeadlineExceeded
 	if err := registry.ListenAndServe(); err != nil {
-		log.Fatal(err)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			logrus.Fatal(err)
+		}
+		logrus.Warn("drain timeout exceeded, in-flight requests aborted")
+	}
+
+	stopRefresh()
+
+	// 刷新 trace 数据后再退出
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := proxy.ShutdownTelemetry(ctx); err != nil {
+		logrus.WithError(err).Error("failed to flush telemetry")
+	}
+	logrus.Info("registry proxy exited")
+}
+
+// loadLimits 从环境变量读取上游限速配置，未配置任何限制时返回 nil
+func loadLimits() (*proxy.Limits, error) {
+	bandwidth, err := proxy.ParseBandwidth(os.Getenv("REGISTRY_UPSTREAM_BANDWIDTH"))
+	if err != nil {
+		return nil, err
+	}
+	upstreams, err := proxy.ParseUpstreamBandwidths(os.Getenv("REGISTRY_UPSTREAM_HOST_BANDWIDTH"))
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := proxy.ParseBandwidthSchedules(os.Getenv("REGISTRY_UPSTREAM_BANDWIDTH_SCHEDULE"))
+	if err != nil {
+		return nil, err
 	}
+	maxConns := 0
+	if value := os.Getenv("REGISTRY_UPSTREAM_MAX_CONNECTIONS"); value != "" {
+		if maxConns, err = strconv.Atoi(value); err != nil || maxConns < 0 {
+			return nil, fmt.Errorf("invalid REGISTRY_UPSTREAM_MAX_CONNECTIONS %q", value)
+		}
+	}
+
+	if bandwidth == 0 && len(upstreams) == 0 && len(schedules) == 0 && maxConns == 0 {
+		return nil, nil
+	}
+	return &proxy.Limits{
+		BytesPerSecond:         bandwidth,
+		UpstreamBytesPerSecond: upstreams,
+		MaxConnections:         maxConns,
+		Schedules:              schedules,
+	}, nil
+}
+
+// loadTagRefresher 从环境变量读取 tag 监视列表，未配置时返回 nil
+func loadTagRefresher() (*proxy.TagRefresher, error) {
+	tags := splitList(os.Getenv("REGISTRY_WATCH_TAGS"))
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	return proxy.NewTagRefresher("http://"+listenAddr, proxy.RefreshConfig{
+		Tags:      tags,
+		Interval:  getEnvDurationOrDefault("REGISTRY_WATCH_INTERVAL", 15*time.Minute),
+		Platforms: splitList(os.Getenv("REGISTRY_WATCH_PLATFORMS")),
+	})
+}
+
+// splitList 拆分逗号分隔的列表，忽略空项
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 func getEnvOrDefault(key, defaultValue string) string {
@@ -35,3 +167,16 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{"key": key, "value": value}).Warnf("invalid duration, using %s", defaultValue)
+		return defaultValue
+	}
+	return d
+}
@@ -1,40 +1,83 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 
 	"github.com/smartcat999/container-ui/internal/handler"
+	"github.com/smartcat999/container-ui/internal/logger"
+	"github.com/smartcat999/container-ui/internal/middleware"
 	"github.com/smartcat999/container-ui/internal/service"
+	"github.com/smartcat999/container-ui/internal/store"
 )
 
 func main() {
+	// 初始化日志，标准库 log 的输出同样会以结构化格式写出
+	appLogger, err := logger.Setup(logger.Config{
+		Level:  getEnvOrDefault("LOG_LEVEL", "info"),
+		Format: getEnvOrDefault("LOG_FORMAT", "json"),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	gin.DebugPrintRouteFunc = func(httpMethod, absolutePath, handlerName string, nuHandlers int) {
+		slog.Debug("route registered", "method", httpMethod, "path", absolutePath, "handler", handlerName)
+	}
+
+	// 打开持久化后端，context 配置保存在 DATA_DIR 下的 contexts.json（json）或 state.db（sqlite）
+	dataDir := getEnvOrDefault("DATA_DIR", ".docker-contexts")
+	backend, err := store.Open(store.Config{
+		Driver: getEnvOrDefault("STORE_DRIVER", store.DriverJSON),
+		Dir:    dataDir,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer backend.Close()
+
 	// 创建 Docker 服务
-	dockerService, err := service.NewDockerService()
+	dockerService, err := service.NewDockerService(backend)
 	if err != nil {
 		log.Fatal(err)
 	}
+	dockerService.SetCLIRegistryAuth(getEnvOrDefault("REGISTRY_AUTH_FROM_DOCKER_CONFIG", "false") == "true")
 	// 创建处理器
 	containerHandler := handler.NewContainerHandler(dockerService)
 	imageHandler := handler.NewImageHandler(dockerService)
 	networkHandler := handler.NewNetworkHandler(dockerService)
 	volumeHandler := handler.NewVolumeHandler(dockerService)
 	contextHandler := handler.NewContextHandler(dockerService)
+	fleetHandler := handler.NewFleetHandler(dockerService)
+	podHandler := handler.NewPodHandler(dockerService)
+	statsHandler := handler.NewStatsHandler(dockerService)
 
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.Recovery(), middleware.RequestID(), middleware.Logger())
 
 	// 配置CORS
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", middleware.RequestIDHeader},
+		ExposeHeaders:    []string{middleware.RequestIDHeader, handler.TotalCountHeader, handler.NextCursorHeader},
 		AllowCredentials: true,
 	}))
 
 	// API路由组
 	api := r.Group("/api")
+	// /api/contexts/current/... 指向当前 context
+	api.Use(contextHandler.ResolveCurrentContext)
 	{
 		// Context 相关路由 - 不需要 context 参数
 		api.GET("/contexts", contextHandler.ListContexts)
@@ -42,21 +85,49 @@ func main() {
 		api.GET("/contexts/:context", contextHandler.GetContextConfig)
 		api.PUT("/contexts/:context", contextHandler.UpdateContextConfig)
 		api.DELETE("/contexts/:context", contextHandler.DeleteContext)
+		// 与 Docker CLI 的 context 存储（~/.docker/contexts）互相导入导出
+		api.POST("/contexts/import", contextHandler.ImportDockerContexts)
+		api.POST("/contexts/export", contextHandler.ExportDockerContexts)
 		// 新增：获取服务器信息路由
 		api.GET("/contexts/:context/info", contextHandler.GetServerInfo)
+		// 设为当前 context
+		api.POST("/contexts/:context/use", contextHandler.UseContext)
+		// 标签和分组
+		api.PUT("/contexts/:context/labels", contextHandler.SetContextLabels)
+		api.GET("/groups", contextHandler.ListGroups)
+
+		// 跨 context 查询，通过 context、label 和 group 参数选择 context
+		api.GET("/fleet/containers", fleetHandler.ListContainers)
+		api.GET("/fleet/images", fleetHandler.ListImages)
+		api.GET("/fleet/volumes", fleetHandler.ListVolumes)
 
 		// 需要 context 参数的资源路由组
 		contextAPI := api.Group("/contexts/:context")
 		{
 			// 容器相关路由
 			contextAPI.GET("/containers", containerHandler.ListContainers)
+			contextAPI.POST("/containers/bulk", containerHandler.BulkContainers)
 			contextAPI.POST("/containers/:id/start", containerHandler.StartContainer)
 			contextAPI.POST("/containers/:id/stop", containerHandler.StopContainer)
+			contextAPI.POST("/containers/:id/restart", containerHandler.RestartContainer)
+			contextAPI.POST("/containers/:id/kill", containerHandler.KillContainer)
+			contextAPI.POST("/containers/:id/pause", containerHandler.PauseContainer)
+			contextAPI.POST("/containers/:id/unpause", containerHandler.UnpauseContainer)
+			contextAPI.POST("/containers/:id/rename", containerHandler.RenameContainer)
+			contextAPI.POST("/containers/:id/update", containerHandler.UpdateContainer)
+			contextAPI.POST("/containers/:id/wait", containerHandler.WaitContainer)
 			contextAPI.DELETE("/containers/:id", containerHandler.DeleteContainer)
 			contextAPI.GET("/containers/:id/json", containerHandler.GetContainerDetail)
 			contextAPI.GET("/containers/:id/logs", containerHandler.GetContainerLogs)
+			contextAPI.GET("/containers/:id/logs/download", containerHandler.DownloadContainerLogs)
 			contextAPI.GET("/containers/:id/exec", containerHandler.ExecContainer)
 
+			// 资源统计路由
+			contextAPI.GET("/containers/:id/stats", statsHandler.ContainerStats)
+			contextAPI.GET("/stats", statsHandler.HostStats)
+			contextAPI.GET("/metrics", statsHandler.ListMetrics)
+			contextAPI.GET("/containers/:id/metrics", statsHandler.ContainerMetrics)
+
 			// 镜像相关路由
 			contextAPI.GET("/images", imageHandler.GetImages)
 			contextAPI.DELETE("/images/:id", imageHandler.DeleteImage)
@@ -72,6 +143,13 @@ func main() {
 			contextAPI.GET("/volumes", volumeHandler.GetVolumes)
 			contextAPI.GET("/volumes/:name", volumeHandler.GetVolumeDetail)
 			contextAPI.DELETE("/volumes/:name", volumeHandler.DeleteVolume)
+
+			// pod 相关路由，仅 Podman context 支持
+			contextAPI.GET("/pods", podHandler.ListPods)
+			contextAPI.GET("/pods/:id", podHandler.GetPodDetail)
+			contextAPI.POST("/pods/:id/start", podHandler.StartPod)
+			contextAPI.POST("/pods/:id/stop", podHandler.StopPod)
+			contextAPI.DELETE("/pods/:id", podHandler.DeletePod)
 		}
 	}
 
@@ -84,5 +162,115 @@ func main() {
 		c.File("./dist/index.html")
 	})
 
-	log.Fatal(r.Run(":8080"))
+	srv := &http.Server{
+		Addr:     ":8080",
+		Handler:  r,
+		ErrorLog: logger.StdLogger(appLogger, slog.LevelError),
+	}
+	// 后台任务在服务退出时停止
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	// 定期从 Docker CLI 的 context 存储同步 context
+	if interval := getEnvDurationOrDefault("DOCKER_CONTEXT_SYNC_INTERVAL", 0); interval > 0 {
+		slog.Info("syncing docker cli contexts", "interval", interval.String())
+		go dockerService.SyncDockerCLIContexts(bgCtx, interval)
+	}
+
+	// 定期检查各 context 的连通性，结果随 /api/contexts 返回
+	if interval := getEnvDurationOrDefault("CONTEXT_HEALTH_INTERVAL", 30*time.Second); interval > 0 {
+		timeout := getEnvDurationOrDefault("CONTEXT_HEALTH_TIMEOUT", 5*time.Second)
+		slog.Info("monitoring context health", "interval", interval.String(), "timeout", timeout.String())
+		go dockerService.MonitorHealth(bgCtx, interval, timeout)
+	}
+
+	// 定期采样容器的资源统计，作为历史数据供图表查询
+	var metricsDone chan struct{}
+	if interval := getEnvDurationOrDefault("METRICS_INTERVAL", 0); interval > 0 {
+		config, err := metricsConfig(interval, dataDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		slog.Info("collecting container metrics", "interval", interval.String(),
+			"raw_retention", config.RawRetention.String(), "resolution", config.Resolution.String(),
+			"retention", config.Retention.String(), "persist_path", config.PersistPath)
+		metricsDone = make(chan struct{})
+		go func() {
+			defer close(metricsDone)
+			if err := dockerService.MonitorMetrics(bgCtx, config); err != nil {
+				slog.Error("metrics collection stopped", "error", err)
+			}
+		}()
+	}
+
+	// 终端会话是被劫持的连接，Shutdown 不会等待它们，需要单独通知关闭
+	srv.RegisterOnShutdown(containerHandler.CloseSessions)
+	srv.RegisterOnShutdown(statsHandler.CloseStreams)
+	srv.RegisterOnShutdown(containerHandler.CloseStreams)
+
+	go func() {
+		slog.Info("starting server", "addr", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	// 等待退出信号，停止接收新请求并等待进行中的请求完成
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	<-ctx.Done()
+	stop()
+
+	shutdownTimeout := getEnvDurationOrDefault("SERVER_SHUTDOWN_TIMEOUT", 10*time.Second)
+	slog.Info("shutting down server", "drain_timeout", shutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("server forced to shutdown", "error", err)
+	}
+	// 停止后台任务，并等待历史统计保存完成
+	stopBackground()
+	if metricsDone != nil {
+		<-metricsDone
+	}
+	slog.Info("server exited")
+}
+
+// metricsConfig 从环境变量读取历史统计的配置
+func metricsConfig(interval time.Duration, dataDir string) (service.MetricsConfig, error) {
+	selector, err := service.ParseContextSelector(
+		[]string{os.Getenv("METRICS_CONTEXTS")}, []string{os.Getenv("METRICS_CONTEXT_LABELS")}, nil)
+	if err != nil {
+		return service.MetricsConfig{}, err
+	}
+	config := service.MetricsConfig{
+		Interval:     interval,
+		Selector:     selector,
+		RawRetention: getEnvDurationOrDefault("METRICS_RAW_RETENTION", time.Hour),
+		Resolution:   getEnvDurationOrDefault("METRICS_RESOLUTION", time.Minute),
+		Retention:    getEnvDurationOrDefault("METRICS_RETENTION", 24*time.Hour),
+	}
+	if getEnvOrDefault("METRICS_PERSIST", "false") == "true" {
+		config.PersistPath = filepath.Join(dataDir, "metrics.json")
+	}
+	return config, config.Validate()
+}
+
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		slog.Warn("invalid duration, using default", "key", key, "value", value, "default", defaultValue.String())
+		return defaultValue
+	}
+	return d
 }
@@ -4,8 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -13,37 +14,91 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 
+	"github.com/smartcat999/container-ui/internal/logger"
 	"github.com/smartcat999/container-ui/internal/service"
 )
 
 type ContainerHandler struct {
 	dockerService *service.DockerService
+	// streams 跟随模式的日志推送
+	streams *eventStreams
+
+	mu       sync.Mutex
+	sessions map[*terminalSession]struct{} // 活跃的终端会话，退出时统一关闭
+}
+
+// terminalSession 包装终端 WebSocket 连接，保证写操作串行
+type terminalSession struct {
+	ws      *websocket.Conn
+	writeMu sync.Mutex
+}
+
+func (t *terminalSession) write(messageType int, data []byte) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	return t.ws.WriteMessage(messageType, data)
 }
 
 func NewContainerHandler(dockerService *service.DockerService) *ContainerHandler {
 	return &ContainerHandler{
 		dockerService: dockerService,
+		streams:       newEventStreams(),
+		sessions:      make(map[*terminalSession]struct{}),
+	}
+}
+
+func (h *ContainerHandler) addSession(session *terminalSession) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sessions[session] = struct{}{}
+}
+
+func (h *ContainerHandler) removeSession(session *terminalSession) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.sessions, session)
+}
+
+// CloseSessions 通知并关闭所有终端会话，在服务退出时调用
+func (h *ContainerHandler) CloseSessions() {
+	h.mu.Lock()
+	sessions := make([]*terminalSession, 0, len(h.sessions))
+	for session := range h.sessions {
+		sessions = append(sessions, session)
+	}
+	h.mu.Unlock()
+
+	for _, session := range sessions {
+		session.write(websocket.TextMessage, []byte("\r\nServer is shutting down, terminal session closed.\r\n"))
+		session.ws.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+			time.Now().Add(time.Second))
+		session.ws.Close()
 	}
 }
 
 // GetContainers 获取容器列表
 func (h *ContainerHandler) GetContainers(c *gin.Context) {
 	contextName := c.Param("context")
-	containers, err := h.dockerService.ListContainers(contextName)
+	opts, ok := listOptions(c, service.ResourceContainer)
+	if !ok {
+		return
+	}
+	page, err := h.dockerService.ListContainers(c.Request.Context(), contextName, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, containers)
+	respondPage(c, page)
 }
 
 // StartContainer 启动容器
 func (h *ContainerHandler) StartContainer(c *gin.Context) {
 	contextName := c.Param("context")
 	id := c.Param("id")
-	err := h.dockerService.StartContainer(contextName, id)
+	err := h.dockerService.StartContainer(c.Request.Context(), contextName, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Container started successfully"})
@@ -53,36 +108,156 @@ func (h *ContainerHandler) StartContainer(c *gin.Context) {
 func (h *ContainerHandler) StopContainer(c *gin.Context) {
 	contextName := c.Param("context")
 	id := c.Param("id")
-	err := h.dockerService.StopContainer(contextName, id)
+	timeout, err := stopTimeout(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = h.dockerService.StopContainer(c.Request.Context(), contextName, id, timeout)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Container stopped successfully"})
 }
 
-// GetContainerDetail 获取容器详情
-func (h *ContainerHandler) GetContainerDetail(c *gin.Context) {
+// RestartContainer 重启容器
+func (h *ContainerHandler) RestartContainer(c *gin.Context) {
 	contextName := c.Param("context")
 	id := c.Param("id")
-	detail, err := h.dockerService.GetContainerDetail(contextName, id)
+	timeout, err := stopTimeout(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, detail)
+	err = h.dockerService.RestartContainer(c.Request.Context(), contextName, id, timeout)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Container restarted successfully"})
+}
+
+// stopTimeout 解析 timeout 参数，单位为秒，-1 表示一直等待，省略时使用默认值
+func stopTimeout(c *gin.Context) (*int, error) {
+	value := c.Query("timeout")
+	if value == "" {
+		return nil, nil
+	}
+	timeout, err := strconv.Atoi(value)
+	if err != nil || timeout < -1 {
+		return nil, fmt.Errorf("invalid timeout %q: must be -1 or a number of seconds", value)
+	}
+	return &timeout, nil
+}
+
+// KillContainer 向容器发送信号，signal 参数如 SIGHUP，默认 SIGKILL
+func (h *ContainerHandler) KillContainer(c *gin.Context) {
+	contextName := c.Param("context")
+	id := c.Param("id")
+	err := h.dockerService.KillContainer(c.Request.Context(), contextName, id, c.Query("signal"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Container killed successfully"})
+}
+
+// PauseContainer 暂停容器
+func (h *ContainerHandler) PauseContainer(c *gin.Context) {
+	contextName := c.Param("context")
+	id := c.Param("id")
+	err := h.dockerService.PauseContainer(c.Request.Context(), contextName, id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Container paused successfully"})
+}
+
+// UnpauseContainer 恢复暂停的容器
+func (h *ContainerHandler) UnpauseContainer(c *gin.Context) {
+	contextName := c.Param("context")
+	id := c.Param("id")
+	err := h.dockerService.UnpauseContainer(c.Request.Context(), contextName, id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Container unpaused successfully"})
+}
+
+// RenameContainer 重命名容器
+func (h *ContainerHandler) RenameContainer(c *gin.Context) {
+	contextName := c.Param("context")
+	id := c.Param("id")
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.dockerService.RenameContainer(c.Request.Context(), contextName, id, req.Name)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Container renamed successfully"})
+}
+
+// UpdateContainer 修改容器的资源限制和重启策略，省略的字段保持不变
+func (h *ContainerHandler) UpdateContainer(c *gin.Context) {
+	contextName := c.Param("context")
+	id := c.Param("id")
+	var req struct {
+		Resources     resourcesRequest `json:"resources"`
+		RestartPolicy string           `json:"restartPolicy"`
+		MaxRetries    int              `json:"maxRetries"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	update := service.ContainerUpdate{
+		Resources:     service.Resources(req.Resources),
+		RestartPolicy: req.RestartPolicy,
+		MaxRetries:    req.MaxRetries,
+	}
+	if err := update.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	warnings, err := h.dockerService.UpdateContainer(c.Request.Context(), contextName, id, update)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Container updated successfully", "warnings": warnings})
 }
 
-// GetContainerLogs 获取容器日志
-func (h *ContainerHandler) GetContainerLogs(c *gin.Context) {
+// WaitContainer 阻塞直到容器退出，condition 参数为 not-running（默认）、next-exit 或 removed
+func (h *ContainerHandler) WaitContainer(c *gin.Context) {
 	contextName := c.Param("context")
 	id := c.Param("id")
-	logs, err := h.dockerService.GetContainerLogs(contextName, id)
+	result, err := h.dockerService.WaitContainer(c.Request.Context(), contextName, id, c.Query("condition"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
-	c.String(http.StatusOK, logs)
+	c.JSON(http.StatusOK, result)
+}
+
+// GetContainerDetail 获取容器详情
+func (h *ContainerHandler) GetContainerDetail(c *gin.Context) {
+	contextName := c.Param("context")
+	id := c.Param("id")
+	detail, err := h.dockerService.GetContainerDetail(c.Request.Context(), contextName, id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, detail)
 }
 
 // DeleteContainer 删除容器
@@ -91,30 +266,78 @@ func (h *ContainerHandler) DeleteContainer(c *gin.Context) {
 	id := c.Param("id")
 	force := c.Query("force") == "true"
 
-	err := h.dockerService.DeleteContainer(contextName, id, force)
+	err := h.dockerService.DeleteContainer(c.Request.Context(), contextName, id, force)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Container deleted successfully"})
 }
 
+// BulkContainers 批量启动、停止、重启或删除容器，按 ID 列表或标签选择容器
+func (h *ContainerHandler) BulkContainers(c *gin.Context) {
+	contextName := c.Param("context")
+	var req struct {
+		Action      string   `json:"action" binding:"required"`
+		IDs         []string `json:"ids"`
+		Labels      []string `json:"labels"`
+		Force       bool     `json:"force"`
+		Timeout     *int     `json:"timeout"`
+		DryRun      bool     `json:"dryRun"`
+		Concurrency int      `json:"concurrency"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	labels, err := service.ParseLabelSelector(req.Labels)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	opts := service.BulkOptions{
+		Action:      req.Action,
+		IDs:         req.IDs,
+		Labels:      labels,
+		Force:       req.Force,
+		Timeout:     req.Timeout,
+		DryRun:      req.DryRun,
+		Concurrency: req.Concurrency,
+	}
+	if err := opts.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.dockerService.BulkContainers(c.Request.Context(), contextName, opts)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
 // ListContainers 列出容器
 func (h *ContainerHandler) ListContainers(c *gin.Context) {
 	contextName := c.Param("context")
-	containers, err := h.dockerService.ListContainers(contextName)
+	opts, ok := listOptions(c, service.ResourceContainer)
+	if !ok {
+		return
+	}
+	page, err := h.dockerService.ListContainers(c.Request.Context(), contextName, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, containers)
+	respondPage(c, page)
 }
 
 // ExecContainer 在容器中执行命令
 func (h *ContainerHandler) ExecContainer(c *gin.Context) {
 	contextName := c.Param("context")
 	id := c.Param("id")
+	l := logger.FromContext(c.Request.Context()).With("context", contextName, "container", id)
 
 	// 升级HTTP连接为WebSocket
 	upgrader := websocket.Upgrader{
@@ -126,11 +349,15 @@ func (h *ContainerHandler) ExecContainer(c *gin.Context) {
 
 	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
-		log.Printf("Failed to upgrade connection: %v", err)
+		l.Error("Failed to upgrade connection", "error", err)
 		return
 	}
 	defer ws.Close()
 
+	session := &terminalSession{ws: ws}
+	h.addSession(session)
+	defer h.removeSession(session)
+
 	// 创建执行配置
 	execConfig := types.ExecConfig{
 		AttachStdin:  true,
@@ -142,18 +369,18 @@ func (h *ContainerHandler) ExecContainer(c *gin.Context) {
 	}
 
 	// 创建执行实例
-	resp, err := h.dockerService.CreateExec(contextName, id, execConfig)
+	resp, err := h.dockerService.CreateExec(c.Request.Context(), contextName, id, execConfig)
 	if err != nil {
-		log.Printf("Failed to create exec: %v", err)
-		ws.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("Error creating exec: %v\n", err)))
+		l.Error("Failed to create exec", "error", err)
+		session.write(websocket.TextMessage, []byte(fmt.Sprintf("Error creating exec: %v\n", err)))
 		return
 	}
 
 	// 附加到执行实例
-	hijackedResp, err := h.dockerService.AttachExec(contextName, resp.ID, execConfig.Tty)
+	hijackedResp, err := h.dockerService.AttachExec(c.Request.Context(), contextName, resp.ID, execConfig.Tty)
 	if err != nil {
-		log.Printf("Failed to attach exec: %v", err)
-		ws.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("Error attaching to exec: %v\n", err)))
+		l.Error("Failed to attach exec", "error", err)
+		session.write(websocket.TextMessage, []byte(fmt.Sprintf("Error attaching to exec: %v\n", err)))
 		return
 	}
 	defer hijackedResp.Close()
@@ -171,7 +398,7 @@ func (h *ContainerHandler) ExecContainer(c *gin.Context) {
 				return
 			}
 			if nr > 0 {
-				err := ws.WriteMessage(websocket.BinaryMessage, buf[:nr])
+				err := session.write(websocket.BinaryMessage, buf[:nr])
 				if err != nil {
 					errChan <- err
 					return
@@ -208,8 +435,8 @@ func (h *ContainerHandler) ExecContainer(c *gin.Context) {
 						return
 					}
 				case "resize":
-					if err := h.dockerService.ResizeExec(contextName, resp.ID, msg.Rows, msg.Cols); err != nil {
-						log.Printf("Failed to resize terminal: %v", err)
+					if err := h.dockerService.ResizeExec(c.Request.Context(), contextName, resp.ID, msg.Rows, msg.Cols); err != nil {
+						l.Error("Failed to resize terminal", "error", err)
 					}
 				}
 			}
@@ -217,13 +444,13 @@ func (h *ContainerHandler) ExecContainer(c *gin.Context) {
 	}()
 
 	// 启动执行实例
-	err = h.dockerService.StartExec(contextName, resp.ID, types.ExecStartCheck{
+	err = h.dockerService.StartExec(c.Request.Context(), contextName, resp.ID, types.ExecStartCheck{
 		Tty:    true,
 		Detach: false,
 	})
 	if err != nil {
-		log.Printf("Failed to start exec: %v", err)
-		ws.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("Error starting exec: %v\n", err)))
+		l.Error("Failed to start exec", "error", err)
+		session.write(websocket.TextMessage, []byte(fmt.Sprintf("Error starting exec: %v\n", err)))
 		return
 	}
 
@@ -231,9 +458,9 @@ func (h *ContainerHandler) ExecContainer(c *gin.Context) {
 	select {
 	case err := <-errChan:
 		if err != io.EOF {
-			log.Printf("Connection error: %v", err)
+			l.Error("Connection error", "error", err)
 		}
 	case <-c.Done():
-		log.Println("Client connection closed")
+		l.Info("Client connection closed")
 	}
 }
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -18,8 +19,14 @@ func NewContextHandler(dockerService *service.DockerService) *ContextHandler {
 	}
 }
 
+// ListContexts 列出 context，可通过 label 和 group 参数筛选
 func (h *ContextHandler) ListContexts(c *gin.Context) {
-	contexts, err := h.dockerService.ListContexts()
+	selector, err := contextSelector(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	contexts, err := h.dockerService.ListContexts(selector)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -59,7 +66,7 @@ func (h *ContextHandler) GetContextConfig(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"host": host})
+	c.JSON(http.StatusOK, gin.H{"name": name, "host": host})
 }
 
 func (h *ContextHandler) UpdateContextConfig(c *gin.Context) {
@@ -81,10 +88,125 @@ func (h *ContextHandler) UpdateContextConfig(c *gin.Context) {
 // GetServerInfo 获取服务器信息
 func (h *ContextHandler) GetServerInfo(c *gin.Context) {
 	contextName := c.Param("context")
-	info, err := h.dockerService.GetServerInfo(contextName)
+	info, err := h.dockerService.GetServerInfo(c.Request.Context(), contextName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, info)
 }
+
+// contextLabels 设置标签和分组的请求体
+type contextLabels struct {
+	Labels map[string]string `json:"labels"`
+	Groups []string          `json:"groups"`
+}
+
+// SetContextLabels 替换 context 的标签和分组
+func (h *ContextHandler) SetContextLabels(c *gin.Context) {
+	name := c.Param("context")
+	var req contextLabels
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.dockerService.SetContextLabels(name, req.Labels, req.Groups); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Context labels updated successfully"})
+}
+
+// ListGroups 列出所有分组
+func (h *ContextHandler) ListGroups(c *gin.Context) {
+	groups, err := h.dockerService.ListGroups()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, groups)
+}
+
+// contextSelector 从 context、label 和 group 查询参数解析筛选条件，如 ?label=env=prod&label=region&group=payments
+func contextSelector(c *gin.Context) (service.ContextSelector, error) {
+	return service.ParseContextSelector(c.QueryArray("context"), c.QueryArray("label"), c.QueryArray("group"))
+}
+
+// UseContext 将 context 设为当前 context
+func (h *ContextHandler) UseContext(c *gin.Context) {
+	name := c.Param("context")
+	if err := h.dockerService.SetCurrentContext(name); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Current context set successfully", "name": name})
+}
+
+// ResolveCurrentContext 将路由中的 context 参数 current 替换为当前 context 的名称，
+// 使 /api/contexts/current/... 与 /api/contexts/<当前 context>/... 等价
+func (h *ContextHandler) ResolveCurrentContext(c *gin.Context) {
+	for i, param := range c.Params {
+		if param.Key != "context" || param.Value != service.CurrentContextAlias {
+			continue
+		}
+		name, err := h.dockerService.CurrentContext()
+		if errors.Is(err, service.ErrNoCurrentContext) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Params[i].Value = name
+	}
+	c.Next()
+}
+
+// ImportDockerContexts 从 Docker CLI 的 context 存储导入 context
+func (h *ContextHandler) ImportDockerContexts(c *gin.Context) {
+	var opts service.DockerCLISyncOptions
+	if err := bindOptionalJSON(c, &opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.dockerService.ImportDockerCLIContexts(opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportDockerContexts 将 context 导出到 Docker CLI 的 context 存储
+func (h *ContextHandler) ExportDockerContexts(c *gin.Context) {
+	var opts service.DockerCLISyncOptions
+	if err := bindOptionalJSON(c, &opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.dockerService.ExportDockerCLIContexts(opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// bindOptionalJSON 解析可选的 JSON 请求体，请求体为空时保留默认值
+func bindOptionalJSON(c *gin.Context, obj interface{}) error {
+	if c.Request.ContentLength == 0 {
+		return nil
+	}
+	return c.ShouldBindJSON(obj)
+}
+
+// errorStatus 返回服务层错误对应的状态码，运行时不支持的操作返回 501
+func errorStatus(err error) int {
+	if errors.Is(err, service.ErrNotSupported) {
+		return http.StatusNotImplemented
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/smartcat999/container-ui/internal/service"
+)
+
+// FleetHandler 跨 context 的查询
+type FleetHandler struct {
+	dockerService *service.DockerService
+}
+
+func NewFleetHandler(dockerService *service.DockerService) *FleetHandler {
+	return &FleetHandler{
+		dockerService: dockerService,
+	}
+}
+
+// ListContainers 列出所选 context 中的容器
+func (h *FleetHandler) ListContainers(c *gin.Context) {
+	query, ok := fleetQuery(c)
+	if !ok {
+		return
+	}
+	result, err := h.dockerService.ListFleetContainers(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ListImages 列出所选 context 中的镜像
+func (h *FleetHandler) ListImages(c *gin.Context) {
+	query, ok := fleetQuery(c)
+	if !ok {
+		return
+	}
+	result, err := h.dockerService.ListFleetImages(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ListVolumes 列出所选 context 中的数据卷
+func (h *FleetHandler) ListVolumes(c *gin.Context) {
+	query, ok := fleetQuery(c)
+	if !ok {
+		return
+	}
+	result, err := h.dockerService.ListFleetVolumes(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// fleetQuery 解析查询参数：context、label、group 选择 context，
+// q 按名称、镜像或 ID 搜索，resourceLabel 按资源的标签筛选，timeout 为单个 context 的超时（如 5s）。
+// 参数错误时直接返回 400
+func fleetQuery(c *gin.Context) (service.FleetQuery, bool) {
+	query, err := parseFleetQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return service.FleetQuery{}, false
+	}
+	return query, true
+}
+
+func parseFleetQuery(c *gin.Context) (service.FleetQuery, error) {
+	selector, err := contextSelector(c)
+	if err != nil {
+		return service.FleetQuery{}, err
+	}
+	labels, err := service.ParseLabelSelector(c.QueryArray("resourceLabel"))
+	if err != nil {
+		return service.FleetQuery{}, err
+	}
+	query := service.FleetQuery{
+		Selector: selector,
+		Search:   c.Query("q"),
+		Labels:   labels,
+	}
+	if value := c.Query("timeout"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 || timeout > service.MaxFleetTimeout {
+			return service.FleetQuery{}, fmt.Errorf("invalid timeout %q: must be a duration between 0 and %s", value, service.MaxFleetTimeout)
+		}
+		query.Timeout = timeout
+	}
+	return query, nil
+}
@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/smartcat999/container-ui/internal/service"
@@ -20,72 +22,148 @@ func NewImageHandler(dockerService *service.DockerService) *ImageHandler {
 // GetImages 获取镜像列表
 func (h *ImageHandler) GetImages(c *gin.Context) {
 	contextName := c.Param("context")
-	images, err := h.dockerService.ListImages(contextName)
+	opts, ok := listOptions(c, service.ResourceImage)
+	if !ok {
+		return
+	}
+	page, err := h.dockerService.ListImages(c.Request.Context(), contextName, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, images)
+	respondPage(c, page)
 }
 
 // DeleteImage 删除镜像
 func (h *ImageHandler) DeleteImage(c *gin.Context) {
 	contextName := c.Param("context")
 	id := c.Param("id")
-	err := h.dockerService.DeleteImage(contextName, id)
+	err := h.dockerService.DeleteImage(c.Request.Context(), contextName, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Image deleted successfully"})
 }
 
-// CreateContainer 从镜像创建容器
-func (h *ImageHandler) CreateContainer(c *gin.Context) {
-	contextName := c.Param("context")
-	var req struct {
-		ImageID string   `json:"imageId"`
-		Name    string   `json:"name"`
-		Command string   `json:"command"`
-		Args    []string `json:"args"`
-		Ports   []struct {
-			Host      uint16 `json:"host"`
-			Container uint16 `json:"container"`
-		} `json:"ports"`
-		Env []struct {
-			Key   string `json:"key"`
-			Value string `json:"value"`
-		} `json:"env"`
-		Volumes []struct {
-			Host      string `json:"host"`
-			Container string `json:"container"`
-			Mode      string `json:"mode"`
-		} `json:"volumes"`
-		RestartPolicy string `json:"restartPolicy"`
-		NetworkMode   string `json:"networkMode"`
-	}
+// resourcesRequest 资源限制，内存单位为字节
+type resourcesRequest struct {
+	CPUs              float64 `json:"cpus"`
+	CPUShares         int64   `json:"cpuShares"`
+	Memory            int64   `json:"memory"`
+	MemoryReservation int64   `json:"memoryReservation"`
+	MemorySwap        int64   `json:"memorySwap"`
+	PidsLimit         int64   `json:"pidsLimit"`
+}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+// createContainerRequest 创建容器的请求体，时间为 Go duration 格式，如 30s；内存和 tmpfs 大小单位为字节
+type createContainerRequest struct {
+	ImageID    string            `json:"imageId"`
+	Name       string            `json:"name"`
+	Command    string            `json:"command"`
+	Args       []string          `json:"args"`
+	Entrypoint []string          `json:"entrypoint"`
+	WorkingDir string            `json:"workingDir"`
+	User       string            `json:"user"`
+	Labels     map[string]string `json:"labels"`
+	Ports      []struct {
+		Host      uint16 `json:"host"`
+		Container uint16 `json:"container"`
+		Protocol  string `json:"protocol"`
+		HostIP    string `json:"hostIp"`
+	} `json:"ports"`
+	Env []struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	} `json:"env"`
+	Volumes []struct {
+		Type      string `json:"type"`
+		Host      string `json:"host"`
+		Container string `json:"container"`
+		Mode      string `json:"mode"`
+		TmpfsSize int64  `json:"tmpfsSize"`
+	} `json:"volumes"`
+	RestartPolicy string `json:"restartPolicy"`
+	MaxRetries    int    `json:"maxRetries"`
+	NetworkMode   string `json:"networkMode"`
+	Networks      []struct {
+		Name        string   `json:"name"`
+		Aliases     []string `json:"aliases"`
+		IPv4Address string   `json:"ipv4Address"`
+		IPv6Address string   `json:"ipv6Address"`
+	} `json:"networks"`
+	Healthcheck *struct {
+		Test        []string `json:"test"`
+		Interval    string   `json:"interval"`
+		Timeout     string   `json:"timeout"`
+		StartPeriod string   `json:"startPeriod"`
+		Retries     int      `json:"retries"`
+	} `json:"healthcheck"`
+	Resources resourcesRequest `json:"resources"`
+	CapAdd    []string         `json:"capAdd"`
+	CapDrop   []string         `json:"capDrop"`
+	Devices   []struct {
+		Host        string `json:"host"`
+		Container   string `json:"container"`
+		Permissions string `json:"permissions"`
+	} `json:"devices"`
+	Ulimits []struct {
+		Name string `json:"name"`
+		Soft int64  `json:"soft"`
+		Hard int64  `json:"hard"`
+	} `json:"ulimits"`
+	LogDriver  string            `json:"logDriver"`
+	LogOptions map[string]string `json:"logOptions"`
+	Platform   string            `json:"platform"`
+	// Start 为 false 时只创建不启动，默认启动
+	Start *bool `json:"start"`
+	// Pull 为 missing 或 always 时在创建前拉取镜像
+	Pull         string `json:"pull"`
+	RegistryAuth *struct {
+		Username      string `json:"username"`
+		Password      string `json:"password"`
+		IdentityToken string `json:"identityToken"`
+		ServerAddress string `json:"serverAddress"`
+	} `json:"registryAuth"`
+}
 
+// containerConfig 将请求体转换为服务层的容器配置
+func (req *createContainerRequest) containerConfig() (service.ContainerConfig, error) {
 	config := service.ContainerConfig{
 		ImageID:       req.ImageID,
 		Name:          req.Name,
 		Command:       req.Command,
 		Args:          req.Args,
+		Entrypoint:    req.Entrypoint,
+		WorkingDir:    req.WorkingDir,
+		User:          req.User,
+		Labels:        req.Labels,
 		Ports:         make([]service.PortMapping, len(req.Ports)),
 		Env:           make([]service.EnvVar, len(req.Env)),
 		Volumes:       make([]service.VolumeMapping, len(req.Volumes)),
 		RestartPolicy: req.RestartPolicy,
+		MaxRetries:    req.MaxRetries,
 		NetworkMode:   req.NetworkMode,
+		Resources:     service.Resources(req.Resources),
+		CapAdd:        req.CapAdd,
+		CapDrop:       req.CapDrop,
+		LogDriver:     req.LogDriver,
+		LogOptions:    req.LogOptions,
+		Platform:      req.Platform,
+		Start:         req.Start == nil || *req.Start,
+		Pull:          req.Pull,
+	}
+	if req.RegistryAuth != nil {
+		auth := service.RegistryAuth(*req.RegistryAuth)
+		config.RegistryAuth = &auth
 	}
 
 	for i, p := range req.Ports {
 		config.Ports[i] = service.PortMapping{
 			Host:      p.Host,
 			Container: p.Container,
+			Protocol:  p.Protocol,
+			HostIP:    p.HostIP,
 		}
 	}
 
@@ -98,28 +176,121 @@ func (h *ImageHandler) CreateContainer(c *gin.Context) {
 
 	for i, v := range req.Volumes {
 		config.Volumes[i] = service.VolumeMapping{
+			Type:      v.Type,
 			Host:      v.Host,
 			Container: v.Container,
 			Mode:      v.Mode,
+			TmpfsSize: v.TmpfsSize,
 		}
 	}
 
-	err := h.dockerService.CreateContainer(contextName, config)
+	for _, n := range req.Networks {
+		config.Networks = append(config.Networks, service.NetworkAttachment(n))
+	}
+
+	if h := req.Healthcheck; h != nil {
+		healthcheck := &service.Healthcheck{Test: h.Test, Retries: h.Retries}
+		for _, d := range []struct {
+			name  string
+			value string
+			dst   *time.Duration
+		}{
+			{"interval", h.Interval, &healthcheck.Interval},
+			{"timeout", h.Timeout, &healthcheck.Timeout},
+			{"startPeriod", h.StartPeriod, &healthcheck.StartPeriod},
+		} {
+			if d.value == "" {
+				continue
+			}
+			value, err := time.ParseDuration(d.value)
+			if err != nil {
+				return service.ContainerConfig{}, fmt.Errorf("invalid healthcheck %s %q: %v", d.name, d.value, err)
+			}
+			*d.dst = value
+		}
+		config.Healthcheck = healthcheck
+	}
+
+	for _, d := range req.Devices {
+		config.Devices = append(config.Devices, service.DeviceMapping(d))
+	}
+
+	for _, u := range req.Ulimits {
+		config.Ulimits = append(config.Ulimits, service.Ulimit(u))
+	}
+
+	return config, config.Validate()
+}
+
+// CreateContainer 从镜像创建容器。
+// 请求头 Accept 为 text/event-stream 时以 SSE 返回：拉取镜像的进度为 progress 事件，最后是 created 或 error 事件
+func (h *ImageHandler) CreateContainer(c *gin.Context) {
+	contextName := c.Param("context")
+	var req createContainerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	config, err := req.containerConfig()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if acceptsEventStream(c) {
+		h.createContainerStream(c, contextName, config)
+		return
+	}
+
+	result, err := h.dockerService.CreateContainer(c.Request.Context(), contextName, config, nil)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), createErrorResponse(result, err))
 		return
 	}
+	c.JSON(http.StatusOK, createResponse(result))
+}
+
+// createContainerStream 以 SSE 返回拉取进度和创建结果，开始推送后状态码固定为 200，错误通过 error 事件返回
+func (h *ImageHandler) createContainerStream(c *gin.Context, contextName string, config service.ContainerConfig) {
+	startEventStream(c)
+
+	progress := func(p service.PullProgress) {
+		sendEvent(c, "progress", p)
+	}
+	result, err := h.dockerService.CreateContainer(c.Request.Context(), contextName, config, progress)
+	if err != nil {
+		sendEvent(c, "error", createErrorResponse(result, err))
+	} else {
+		sendEvent(c, "created", createResponse(result))
+	}
+}
+
+func createResponse(result service.CreateContainerResult) gin.H {
+	return gin.H{
+		"message":  "Container created successfully",
+		"id":       result.ID,
+		"warnings": result.Warnings,
+		"started":  result.Started,
+	}
+}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Container created successfully"})
+func createErrorResponse(result service.CreateContainerResult, err error) gin.H {
+	resp := gin.H{"error": err.Error()}
+	// 启动失败且未能删除容器时返回残留容器的 ID
+	if result.ID != "" {
+		resp["id"] = result.ID
+	}
+	return resp
 }
 
 // GetImageDetail 获取镜像详情
 func (h *ImageHandler) GetImageDetail(c *gin.Context) {
 	contextName := c.Param("context")
 	id := c.Param("id")
-	detail, err := h.dockerService.GetImageDetail(contextName, id)
+	detail, err := h.dockerService.GetImageDetail(c.Request.Context(), contextName, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, detail)
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/smartcat999/container-ui/internal/service"
+)
+
+// 分页信息通过响应头返回，响应体仍为数组
+const (
+	TotalCountHeader = "X-Total-Count"
+	NextCursorHeader = "X-Next-Cursor"
+)
+
+// listOptions 解析列表的查询参数：status、label、name、ancestor、dangling 筛选，
+// sort、order（asc 或 desc）排序，limit、cursor 分页，两者都未指定时返回全部。参数错误时直接返回 400
+func listOptions(c *gin.Context, resource string) (service.ListOptions, bool) {
+	opts, err := parseListOptions(c)
+	if err == nil {
+		err = opts.Validate(resource)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return service.ListOptions{}, false
+	}
+	return opts, true
+}
+
+func parseListOptions(c *gin.Context) (service.ListOptions, error) {
+	labels, err := service.ParseLabelSelector(c.QueryArray("label"))
+	if err != nil {
+		return service.ListOptions{}, err
+	}
+	opts := service.ListOptions{
+		Filter: service.ListFilter{
+			Status:   queryList(c, "status"),
+			Labels:   labels,
+			Name:     queryList(c, "name"),
+			Ancestor: queryList(c, "ancestor"),
+		},
+		Sort:   c.Query("sort"),
+		Cursor: c.Query("cursor"),
+	}
+	if value := c.Query("dangling"); value != "" {
+		dangling, err := strconv.ParseBool(value)
+		if err != nil {
+			return service.ListOptions{}, fmt.Errorf("invalid dangling %q: must be true or false", value)
+		}
+		opts.Filter.Dangling = &dangling
+	}
+	switch order := c.Query("order"); order {
+	case "", "asc":
+	case "desc":
+		opts.Desc = true
+	default:
+		return service.ListOptions{}, fmt.Errorf("invalid order %q: must be asc or desc", order)
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return service.ListOptions{}, fmt.Errorf("invalid limit %q: must be a positive integer", value)
+		}
+		opts.Limit = limit
+	} else if opts.Cursor != "" {
+		opts.Limit = service.DefaultListLimit
+	}
+	return opts, nil
+}
+
+// queryList 读取可重复、可逗号分隔的查询参数
+func queryList(c *gin.Context, key string) []string {
+	var items []string
+	for _, value := range c.QueryArray(key) {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// respondPage 返回一页列表，总数和下一页游标放在响应头中
+func respondPage[T any](c *gin.Context, page *service.ListPage[T]) {
+	c.Header(TotalCountHeader, strconv.Itoa(page.Total))
+	if page.NextCursor != "" {
+		c.Header(NextCursorHeader, page.NextCursor)
+	}
+	c.JSON(http.StatusOK, page.Items)
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/smartcat999/container-ui/internal/logger"
+	"github.com/smartcat999/container-ui/internal/service"
+)
+
+// CloseStreams 结束所有跟随模式的日志推送，在服务退出时调用
+func (h *ContainerHandler) CloseStreams() {
+	h.streams.close()
+}
+
+// GetContainerLogs 获取容器日志。
+// follow=true 或请求头 Accept 为 text/event-stream 时以 SSE 推送，每行一个 log 事件，读取结束时发送 end 事件；
+// 否则以纯文本返回，格式与 docker logs 一致
+func (h *ContainerHandler) GetContainerLogs(c *gin.Context) {
+	contextName := c.Param("context")
+	id := c.Param("id")
+	opts, err := logOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if opts.Follow || acceptsEventStream(c) {
+		ctx, cancel := h.streams.context(c)
+		defer cancel()
+		stream := newLazyEventStream(c)
+		err := h.dockerService.StreamContainerLogs(ctx, contextName, id, opts, func(line service.LogLine) error {
+			stream.send("log", line)
+			return nil
+		})
+		if err == nil {
+			stream.send("end", gin.H{})
+		}
+		stream.finish(err)
+		return
+	}
+
+	started := false
+	err = h.dockerService.StreamContainerLogs(c.Request.Context(), contextName, id, opts, func(line service.LogLine) error {
+		if !started {
+			c.Header("Content-Type", "text/plain; charset=utf-8")
+			c.Status(http.StatusOK)
+			started = true
+		}
+		_, err := c.Writer.WriteString(line.String() + "\n")
+		return err
+	})
+	switch {
+	case err != nil && !started:
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+	case !started:
+		c.String(http.StatusOK, "")
+	}
+}
+
+// 日志下载的格式
+const (
+	logFormatText  = "text"
+	logFormatJSONL = "jsonl"
+)
+
+// logDownloadErrorPrefix 文本格式的日志下载中途出错时，末尾错误行的前缀
+const logDownloadErrorPrefix = "### log download failed: "
+
+// DownloadContainerLogs 以 gzip 压缩的附件下载容器日志，默认为全部日志，参数同 GetContainerLogs（不支持 follow）。
+// format=jsonl 时每行为一个 JSON 对象，包含 stream、timestamp 和 text 字段。
+// 开始传输后出错时在文件末尾写入错误记录（jsonl 为 {"error": ...}，text 为 logDownloadErrorPrefix 开头的一行）
+func (h *ContainerHandler) DownloadContainerLogs(c *gin.Context) {
+	contextName := c.Param("context")
+	id := c.Param("id")
+	opts, err := logOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	opts.Follow = false
+	if c.Query("tail") == "" {
+		opts.Tail = "all"
+	}
+	format := c.DefaultQuery("format", logFormatText)
+	switch format {
+	case logFormatText:
+	case logFormatJSONL:
+		// JSON lines 总是带时间戳，便于按时间合并多个容器的日志
+		opts.Timestamps = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid format %q: must be text or jsonl", format)})
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s.log.gz", sanitizeFilename(id), time.Now().UTC().Format("20060102-150405"))
+	if format == logFormatJSONL {
+		filename = strings.TrimSuffix(filename, ".log.gz") + ".jsonl.gz"
+	}
+	var (
+		gz      *gzip.Writer
+		encoder *json.Encoder
+	)
+	// 读到第一行时才写入响应头，之前的错误仍能以对应的状态码返回
+	start := func() {
+		c.Header("Content-Type", "application/gzip")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Status(http.StatusOK)
+		gz = gzip.NewWriter(c.Writer)
+		encoder = json.NewEncoder(gz)
+	}
+	err = h.dockerService.StreamContainerLogs(c.Request.Context(), contextName, id, opts, func(line service.LogLine) error {
+		if gz == nil {
+			start()
+		}
+		if format == logFormatJSONL {
+			return encoder.Encode(line)
+		}
+		_, err := io.WriteString(gz, line.String()+"\n")
+		return err
+	})
+	switch {
+	case err != nil && gz == nil:
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+	case err != nil:
+		logger.FromContext(c.Request.Context()).Warn("log download interrupted", "context", contextName, "container", id, "error", err)
+		// 状态码已经发送，在文件末尾写入错误，避免不完整的日志被当作完整的
+		if format == logFormatJSONL {
+			encoder.Encode(gin.H{"error": err.Error()})
+		} else {
+			io.WriteString(gz, logDownloadErrorPrefix+err.Error()+"\n")
+		}
+		gz.Close()
+	default:
+		if gz == nil {
+			// 没有日志时返回空的压缩文件
+			start()
+		}
+		gz.Close()
+	}
+}
+
+// sanitizeFilename 将容器名称或 ID 中不适合作为文件名的字符替换为 _
+func sanitizeFilename(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// logOptions 解析日志的查询参数：follow、since、until、tail、timestamps（默认 true）、
+// stdout 和 stderr（默认都为 true）、grep、regex
+func logOptions(c *gin.Context) (service.LogOptions, error) {
+	opts := service.LogOptions{
+		Since: c.Query("since"),
+		Until: c.Query("until"),
+		Tail:  c.Query("tail"),
+		Grep:  c.Query("grep"),
+		Regex: c.Query("regex"),
+	}
+	for _, param := range []struct {
+		name  string
+		value bool
+		dst   *bool
+	}{
+		{"follow", false, &opts.Follow},
+		{"timestamps", true, &opts.Timestamps},
+		{"stdout", true, &opts.Stdout},
+		{"stderr", true, &opts.Stderr},
+	} {
+		*param.dst = param.value
+		if value := c.Query(param.name); value != "" {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return service.LogOptions{}, fmt.Errorf("invalid %s %q: must be true or false", param.name, value)
+			}
+			*param.dst = b
+		}
+	}
+	return opts, opts.Validate()
+}
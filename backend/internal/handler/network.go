@@ -20,21 +20,25 @@ func NewNetworkHandler(dockerService *service.DockerService) *NetworkHandler {
 // GetNetworks 获取网络列表
 func (h *NetworkHandler) GetNetworks(c *gin.Context) {
 	contextName := c.Param("context")
-	networks, err := h.dockerService.ListNetworks(contextName)
+	opts, ok := listOptions(c, service.ResourceNetwork)
+	if !ok {
+		return
+	}
+	page, err := h.dockerService.ListNetworks(c.Request.Context(), contextName, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, networks)
+	respondPage(c, page)
 }
 
 // GetNetworkDetail 获取网络详情
 func (h *NetworkHandler) GetNetworkDetail(c *gin.Context) {
 	contextName := c.Param("context")
 	id := c.Param("id")
-	detail, err := h.dockerService.GetNetworkDetail(contextName, id)
+	detail, err := h.dockerService.GetNetworkDetail(c.Request.Context(), contextName, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, detail)
@@ -44,9 +48,9 @@ func (h *NetworkHandler) GetNetworkDetail(c *gin.Context) {
 func (h *NetworkHandler) DeleteNetwork(c *gin.Context) {
 	contextName := c.Param("context")
 	id := c.Param("id")
-	err := h.dockerService.DeleteNetwork(contextName, id)
+	err := h.dockerService.DeleteNetwork(c.Request.Context(), contextName, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Network deleted successfully"})
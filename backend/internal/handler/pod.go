@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/smartcat999/container-ui/internal/service"
+)
+
+// PodHandler Podman pod 的接口，其他运行时返回 501
+type PodHandler struct {
+	dockerService *service.DockerService
+}
+
+func NewPodHandler(dockerService *service.DockerService) *PodHandler {
+	return &PodHandler{
+		dockerService: dockerService,
+	}
+}
+
+// ListPods 获取 pod 列表
+func (h *PodHandler) ListPods(c *gin.Context) {
+	contextName := c.Param("context")
+	pods, err := h.dockerService.ListPods(c.Request.Context(), contextName)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, pods)
+}
+
+// GetPodDetail 获取 pod 详情
+func (h *PodHandler) GetPodDetail(c *gin.Context) {
+	contextName := c.Param("context")
+	id := c.Param("id")
+	detail, err := h.dockerService.GetPodDetail(c.Request.Context(), contextName, id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// StartPod 启动 pod
+func (h *PodHandler) StartPod(c *gin.Context) {
+	contextName := c.Param("context")
+	id := c.Param("id")
+	if err := h.dockerService.StartPod(c.Request.Context(), contextName, id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Pod started successfully"})
+}
+
+// StopPod 停止 pod
+func (h *PodHandler) StopPod(c *gin.Context) {
+	contextName := c.Param("context")
+	id := c.Param("id")
+	if err := h.dockerService.StopPod(c.Request.Context(), contextName, id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Pod stopped successfully"})
+}
+
+// DeletePod 删除 pod，force=true 时同时停止运行中的容器
+func (h *PodHandler) DeletePod(c *gin.Context) {
+	contextName := c.Param("context")
+	id := c.Param("id")
+	force := c.Query("force") == "true"
+	if err := h.dockerService.DeletePod(c.Request.Context(), contextName, id, force); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Pod deleted successfully"})
+}
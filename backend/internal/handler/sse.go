@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// acceptsEventStream 判断客户端是否请求 SSE
+func acceptsEventStream(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), "text/event-stream")
+}
+
+// startEventStream 写入 SSE 响应头，之后状态码固定为 200，错误需通过事件返回
+func startEventStream(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	// 避免 nginx 等反向代理缓冲事件
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+}
+
+// sendEvent 发送一个 SSE 事件并立即刷新
+func sendEvent(c *gin.Context, event string, data any) {
+	c.SSEvent(event, data)
+	c.Writer.Flush()
+}
+
+// eventStreams 用于在服务退出时结束 SSE 推送。SSE 请求不会自行结束，Shutdown 会一直等待它们
+type eventStreams struct {
+	// done 在服务退出时关闭
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newEventStreams() *eventStreams {
+	return &eventStreams{done: make(chan struct{})}
+}
+
+func (s *eventStreams) close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+// context 返回在客户端断开或服务退出时取消的 context
+func (s *eventStreams) context(c *gin.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	go func() {
+		select {
+		case <-s.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// lazyEventStream 在发送第一个事件时才开始 SSE 响应，
+// 使容器不存在、运行时不支持等在推送前发生的错误仍能以对应的状态码返回
+type lazyEventStream struct {
+	c       *gin.Context
+	started bool
+}
+
+func newLazyEventStream(c *gin.Context) *lazyEventStream {
+	return &lazyEventStream{c: c}
+}
+
+func (s *lazyEventStream) send(event string, data any) {
+	if !s.started {
+		startEventStream(s.c)
+		s.started = true
+	}
+	sendEvent(s.c, event, data)
+}
+
+// finish 结束推送，开始推送后的错误通过 error 事件返回
+func (s *lazyEventStream) finish(err error) {
+	switch {
+	case err == nil:
+		if !s.started {
+			// 没有任何事件时返回空的事件流
+			startEventStream(s.c)
+		}
+	case s.started:
+		sendEvent(s.c, "error", gin.H{"error": err.Error()})
+	default:
+		s.c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/smartcat999/container-ui/internal/service"
+)
+
+// StatsHandler 容器资源统计
+type StatsHandler struct {
+	dockerService *service.DockerService
+	streams       *eventStreams
+}
+
+func NewStatsHandler(dockerService *service.DockerService) *StatsHandler {
+	return &StatsHandler{
+		dockerService: dockerService,
+		streams:       newEventStreams(),
+	}
+}
+
+// CloseStreams 结束所有推送
+func (h *StatsHandler) CloseStreams() {
+	h.streams.close()
+}
+
+// ContainerStats 以 SSE 持续推送容器的资源统计，每次采样一个 stats 事件；stream=false 时返回一次统计
+func (h *StatsHandler) ContainerStats(c *gin.Context) {
+	contextName := c.Param("context")
+	id := c.Param("id")
+
+	if c.Query("stream") == "false" {
+		stats, err := h.dockerService.GetContainerStats(c.Request.Context(), contextName, id)
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, stats)
+		return
+	}
+
+	ctx, cancel := h.streams.context(c)
+	defer cancel()
+	stream := newLazyEventStream(c)
+	err := h.dockerService.StreamContainerStats(ctx, contextName, id, func(stats service.ContainerStats) {
+		stream.send("stats", stats)
+	})
+	stream.finish(err)
+}
+
+// HostStats 以 SSE 推送 context 中所有运行中容器的统计及其汇总，interval 为推送间隔，如 5s，默认 1s
+func (h *StatsHandler) HostStats(c *gin.Context) {
+	contextName := c.Param("context")
+	interval := service.DefaultStatsInterval
+	if value := c.Query("interval"); value != "" {
+		var err error
+		interval, err = time.ParseDuration(value)
+		if err != nil || interval < time.Second || interval > service.MaxStatsInterval {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid interval %q: must be a duration between 1s and %s", value, service.MaxStatsInterval)})
+			return
+		}
+	}
+
+	ctx, cancel := h.streams.context(c)
+	defer cancel()
+	stream := newLazyEventStream(c)
+	err := h.dockerService.StreamHostStats(ctx, contextName, interval, func(snapshot service.HostStatsSnapshot) {
+		stream.send("stats", snapshot)
+	})
+	stream.finish(err)
+}
+
+// ListMetrics 列出 context 中有历史统计的容器
+func (h *StatsHandler) ListMetrics(c *gin.Context) {
+	contextName := c.Param("context")
+	series, err := h.dockerService.ListMetricSeries(contextName)
+	if err != nil {
+		c.JSON(metricsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, series)
+}
+
+// ContainerMetrics 查询容器的历史统计。from、to 为 RFC 3339 格式的时间，
+// 也可以用 since 指定最近一段时间，如 6h；都省略时返回保留的所有数据
+func (h *StatsHandler) ContainerMetrics(c *gin.Context) {
+	contextName := c.Param("context")
+	id := c.Param("id")
+	from, to, err := metricsRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	metrics, err := h.dockerService.GetContainerMetrics(contextName, id, from, to)
+	if err != nil {
+		c.JSON(metricsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, metrics)
+}
+
+func metricsRange(c *gin.Context) (time.Time, time.Time, error) {
+	var from, to time.Time
+	for _, param := range []struct {
+		name string
+		dst  *time.Time
+	}{
+		{"from", &from},
+		{"to", &to},
+	} {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid %s %q: must be an RFC 3339 time", param.name, value)
+		}
+		*param.dst = t
+	}
+	if value := c.Query("since"); value != "" {
+		if !from.IsZero() {
+			return time.Time{}, time.Time{}, fmt.Errorf("since and from cannot be used together")
+		}
+		since, err := time.ParseDuration(value)
+		if err != nil || since <= 0 {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid since %q: must be a positive duration", value)
+		}
+		from = time.Now().Add(-since)
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("to must not be before from")
+	}
+	return from, to, nil
+}
+
+func metricsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrMetricsDisabled):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, service.ErrNoMetrics):
+		return http.StatusNotFound
+	default:
+		return errorStatus(err)
+	}
+}
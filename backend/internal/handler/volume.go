@@ -21,21 +21,25 @@ func NewVolumeHandler(dockerService *service.DockerService) *VolumeHandler {
 // GetVolumes 获取数据卷列表
 func (h *VolumeHandler) GetVolumes(c *gin.Context) {
 	contextName := c.Param("context")
-	volumes, err := h.dockerService.ListVolumes(contextName)
+	opts, ok := listOptions(c, service.ResourceVolume)
+	if !ok {
+		return
+	}
+	page, err := h.dockerService.ListVolumes(c.Request.Context(), contextName, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, volumes)
+	respondPage(c, page)
 }
 
 // GetVolumeDetail 获取数据卷详情
 func (h *VolumeHandler) GetVolumeDetail(c *gin.Context) {
 	contextName := c.Param("context")
 	name := c.Param("name")
-	detail, err := h.dockerService.GetVolumeDetail(contextName, name)
+	detail, err := h.dockerService.GetVolumeDetail(c.Request.Context(), contextName, name)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, detail)
@@ -45,9 +49,9 @@ func (h *VolumeHandler) GetVolumeDetail(c *gin.Context) {
 func (h *VolumeHandler) DeleteVolume(c *gin.Context) {
 	contextName := c.Param("context")
 	name := c.Param("name")
-	err := h.dockerService.DeleteVolume(contextName, name)
+	err := h.dockerService.DeleteVolume(c.Request.Context(), contextName, name)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Volume deleted successfully"})
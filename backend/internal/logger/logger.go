@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Config 日志配置
+type Config struct {
+	Level  string // debug/info/warn/error
+	Format string // json/text
+}
+
+// 日志中需要脱敏的字段名（小写匹配子串）
+var sensitiveKeys = []string{"password", "secret", "token", "authorization", "credential"}
+
+const redacted = "[REDACTED]"
+
+type requestIDKey struct{}
+
+// Setup 按配置创建 slog 日志并设为全局默认，标准库 log 的输出也会转到该日志
+func Setup(cfg Config) (*slog.Logger, error) {
+	l, err := New(os.Stdout, cfg)
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(l)
+	return l, nil
+}
+
+// New 创建写入 w 的 slog 日志
+func New(w io.Writer, cfg Config) (*slog.Logger, error) {
+	var level slog.Level
+	if cfg.Level != "" {
+		if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+			return nil, fmt.Errorf("invalid log level %q: %v", cfg.Level, err)
+		}
+	}
+
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactAttr,
+	}
+
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "json":
+		handler = slog.NewJSONHandler(w, opts)
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unsupported log format %q", cfg.Format)
+	}
+	return slog.New(handler), nil
+}
+
+// redactAttr 屏蔽密码、令牌等敏感字段的值
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	if IsSensitiveKey(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+	return a
+}
+
+// IsSensitiveKey 判断字段名是否可能包含敏感信息
+func IsSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithRequestID 将请求 ID 写入 context
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID 从 context 中读取请求 ID
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// FromContext 返回携带请求 ID 的日志
+func FromContext(ctx context.Context) *slog.Logger {
+	l := slog.Default()
+	if id := RequestID(ctx); id != "" {
+		l = l.With("request_id", id)
+	}
+	return l
+}
+
+// StdLogger 返回写入 slog 的标准库 log，用于只接受 *log.Logger 的第三方库
+func StdLogger(l *slog.Logger, level slog.Level) *log.Logger {
+	return slog.NewLogLogger(l.Handler(), level)
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 批量操作
+const (
+	BulkActionStart   = "start"
+	BulkActionStop    = "stop"
+	BulkActionRestart = "restart"
+	BulkActionRemove  = "remove"
+)
+
+const (
+	// DefaultBulkConcurrency 批量操作默认同时处理的容器数量
+	DefaultBulkConcurrency = 4
+	// MaxBulkConcurrency 允许的最大并发数，避免同时向 daemon 发出过多请求
+	MaxBulkConcurrency = 16
+)
+
+// 批量操作中单个容器的结果
+const (
+	BulkStatusOK    = "ok"
+	BulkStatusError = "error"
+	// BulkStatusPlanned dry-run 时表示会被操作的容器
+	BulkStatusPlanned = "planned"
+)
+
+// BulkOptions 批量操作的参数，IDs 和 Labels 至少指定一个，同时指定时需同时满足
+type BulkOptions struct {
+	Action string
+	// IDs 容器 ID、ID 前缀或名称
+	IDs    []string
+	Labels LabelSelector
+	// Force 用于 remove，删除运行中的容器
+	Force bool
+	// Timeout 用于 stop 和 restart，含义与 StopContainer 相同
+	Timeout *int
+	// DryRun 只返回会被操作的容器，不执行操作
+	DryRun bool
+	// Concurrency 同时处理的容器数量，为 0 时使用 DefaultBulkConcurrency
+	Concurrency int
+}
+
+// Validate 校验批量操作的参数
+func (o BulkOptions) Validate() error {
+	switch o.Action {
+	case BulkActionStart, BulkActionStop, BulkActionRestart, BulkActionRemove:
+	default:
+		return fmt.Errorf("invalid action %q: must be start, stop, restart or remove", o.Action)
+	}
+	// 不允许不带条件地操作所有容器
+	if len(o.IDs) == 0 && len(o.Labels) == 0 {
+		return fmt.Errorf("ids or labels are required")
+	}
+	if o.Concurrency < 0 || o.Concurrency > MaxBulkConcurrency {
+		return fmt.Errorf("concurrency must be between 1 and %d", MaxBulkConcurrency)
+	}
+	return validateStopTimeout(o.Timeout)
+}
+
+// BulkItemResult 单个容器的操作结果
+type BulkItemResult struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	State  string `json:"state"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BulkResult 批量操作的结果，未找到的 ID 作为失败项排在最前，其余按容器名称排序
+type BulkResult struct {
+	Action    string           `json:"action"`
+	DryRun    bool             `json:"dryRun"`
+	Results   []BulkItemResult `json:"results"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+}
+
+// BulkContainers 对一组容器并发执行同一操作，单个容器失败不影响其他容器
+func (s *DockerService) BulkContainers(ctx context.Context, contextName string, opts BulkOptions) (*BulkResult, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	page, err := s.ListContainers(ctx, contextName, ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	containers := page.Items
+
+	result := &BulkResult{Action: opts.Action, DryRun: opts.DryRun, Results: []BulkItemResult{}}
+	targets, missing := selectBulkTargets(containers, opts)
+	for _, id := range missing {
+		result.Results = append(result.Results, BulkItemResult{
+			ID:     id,
+			Status: BulkStatusError,
+			Error:  fmt.Sprintf("no unique container matches %s", id),
+		})
+	}
+
+	items := make([]BulkItemResult, len(targets))
+	for i, c := range targets {
+		items[i] = BulkItemResult{ID: c.ID, Name: c.Name, State: c.State, Status: BulkStatusPlanned}
+	}
+	if !opts.DryRun {
+		concurrency := opts.Concurrency
+		if concurrency == 0 {
+			concurrency = DefaultBulkConcurrency
+		}
+		sem := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+		for i := range items {
+			wg.Add(1)
+			go func(item *BulkItemResult) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				if err := s.bulkAction(ctx, contextName, item.ID, opts); err != nil {
+					item.Status = BulkStatusError
+					item.Error = err.Error()
+				} else {
+					item.Status = BulkStatusOK
+				}
+			}(&items[i])
+		}
+		wg.Wait()
+	}
+
+	result.Results = append(result.Results, items...)
+	for _, item := range result.Results {
+		switch item.Status {
+		case BulkStatusOK:
+			result.Succeeded++
+		case BulkStatusError:
+			result.Failed++
+		}
+	}
+	return result, nil
+}
+
+func (s *DockerService) bulkAction(ctx context.Context, contextName, id string, opts BulkOptions) error {
+	switch opts.Action {
+	case BulkActionStart:
+		return s.StartContainer(ctx, contextName, id)
+	case BulkActionStop:
+		return s.StopContainer(ctx, contextName, id, opts.Timeout)
+	case BulkActionRestart:
+		return s.RestartContainer(ctx, contextName, id, opts.Timeout)
+	default:
+		return s.DeleteContainer(ctx, contextName, id, opts.Force)
+	}
+}
+
+// selectBulkTargets 按 ID、名称和标签选择容器，返回按名称排序的容器和未找到的 ID。
+// 一个 ID 前缀匹配多个容器时视为未找到，避免误操作。
+// 列表中的 ID 只有前 12 位，完整的 64 位 ID 按该前缀匹配
+func selectBulkTargets(containers []ContainerInfo, opts BulkOptions) ([]ContainerInfo, []string) {
+	selected := make(map[int]bool)
+	var missing []string
+	for _, id := range opts.IDs {
+		match := -1
+		for i, c := range containers {
+			if c.Name == id || c.ID == id {
+				match = i
+				break
+			}
+		}
+		if match < 0 {
+			for i, c := range containers {
+				if !strings.HasPrefix(c.ID, id) && !strings.HasPrefix(id, c.ID) {
+					continue
+				}
+				if match >= 0 {
+					match = -1
+					break
+				}
+				match = i
+			}
+		}
+		if match < 0 {
+			missing = append(missing, id)
+			continue
+		}
+		selected[match] = true
+	}
+
+	var targets []ContainerInfo
+	for i, c := range containers {
+		if len(opts.IDs) > 0 && !selected[i] {
+			continue
+		}
+		if !opts.Labels.Matches(c.Labels) {
+			continue
+		}
+		targets = append(targets, c)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].Name < targets[j].Name
+	})
+	return targets, missing
+}
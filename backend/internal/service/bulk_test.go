@@ -0,0 +1,44 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelectBulkTargets(t *testing.T) {
+	full := "3f4e8c2a91b7" + strings.Repeat("0", 52)
+	containers := []ContainerInfo{
+		{ID: "3f4e8c2a91b7", Name: "web"},
+		{ID: "3f4e11111111", Name: "db"},
+		{ID: "a1b2c3d4e5f6", Name: "cache"},
+	}
+	tests := []struct {
+		name        string
+		ids         []string
+		wantTargets []string
+		wantMissing []string
+	}{
+		{"name", []string{"cache"}, []string{"cache"}, nil},
+		{"short id", []string{"3f4e8c2a91b7"}, []string{"web"}, nil},
+		{"prefix", []string{"a1b2"}, []string{"cache"}, nil},
+		{"full id", []string{full}, []string{"web"}, nil},
+		{"ambiguous prefix", []string{"3f4e"}, nil, []string{"3f4e"}},
+		{"unknown full id", []string{"ffff" + full[4:]}, nil, []string{"ffff" + full[4:]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, missing := selectBulkTargets(containers, BulkOptions{Action: BulkActionStop, IDs: tt.ids})
+			var names []string
+			for _, c := range targets {
+				names = append(names, c.Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantTargets) {
+				t.Errorf("targets = %v, want %v", names, tt.wantTargets)
+			}
+			if !reflect.DeepEqual(missing, tt.wantMissing) {
+				t.Errorf("missing = %v, want %v", missing, tt.wantMissing)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// containerNamePattern 与 Docker 的容器名规则一致
+var containerNamePattern = regexp.MustCompile(`^/?[a-zA-Z0-9][a-zA-Z0-9_.-]+$`)
+
+// Validate 校验容器配置，在调用运行时之前发现错误，避免创建出配置不完整的容器
+func (c ContainerConfig) Validate() error {
+	if c.ImageID == "" {
+		return fmt.Errorf("image is required")
+	}
+	if c.Name != "" && !containerNamePattern.MatchString(c.Name) {
+		return fmt.Errorf("invalid container name %q", c.Name)
+	}
+
+	for _, p := range c.Ports {
+		if p.Container == 0 {
+			return fmt.Errorf("container port is required")
+		}
+		switch p.Protocol {
+		case "", "tcp", "udp", "sctp":
+		default:
+			return fmt.Errorf("invalid protocol %q for port %d: must be tcp, udp or sctp", p.Protocol, p.Container)
+		}
+		if p.HostIP != "" && net.ParseIP(p.HostIP) == nil {
+			return fmt.Errorf("invalid host ip %q for port %d", p.HostIP, p.Container)
+		}
+	}
+
+	for _, e := range c.Env {
+		if e.Key == "" || strings.Contains(e.Key, "=") {
+			return fmt.Errorf("invalid environment variable name %q", e.Key)
+		}
+	}
+
+	for _, v := range c.Volumes {
+		if !path.IsAbs(v.Container) {
+			return fmt.Errorf("container path %q must be absolute", v.Container)
+		}
+		switch v.Type {
+		case "", VolumeTypeBind:
+			if v.Host == "" {
+				return fmt.Errorf("host path is required for bind mount %s", v.Container)
+			}
+		case VolumeTypeVolume:
+			// Host 为空时创建匿名数据卷
+		case VolumeTypeTmpfs:
+			if v.TmpfsSize < 0 {
+				return fmt.Errorf("invalid tmpfs size for %s", v.Container)
+			}
+		default:
+			return fmt.Errorf("invalid volume type %q: must be bind, volume or tmpfs", v.Type)
+		}
+		if v.Type != "" && v.Type != VolumeTypeBind && v.Mode != "" && v.Mode != "rw" && v.Mode != "ro" {
+			return fmt.Errorf("invalid mode %q for %s: must be rw or ro", v.Mode, v.Container)
+		}
+	}
+
+	if err := validateRestartPolicy(c.RestartPolicy, c.MaxRetries); err != nil {
+		return err
+	}
+
+	if len(c.Networks) > 0 && (c.NetworkMode == "host" || c.NetworkMode == "none") {
+		return fmt.Errorf("networks cannot be used with network mode %s", c.NetworkMode)
+	}
+	seenNetworks := make(map[string]bool, len(c.Networks))
+	for _, n := range c.Networks {
+		if n.Name == "" {
+			return fmt.Errorf("network name is required")
+		}
+		if seenNetworks[n.Name] {
+			return fmt.Errorf("network %s is specified more than once", n.Name)
+		}
+		seenNetworks[n.Name] = true
+		if n.IPv4Address != "" {
+			if ip := net.ParseIP(n.IPv4Address); ip == nil || ip.To4() == nil {
+				return fmt.Errorf("invalid ipv4 address %q for network %s", n.IPv4Address, n.Name)
+			}
+		}
+		if n.IPv6Address != "" {
+			if ip := net.ParseIP(n.IPv6Address); ip == nil || ip.To4() != nil {
+				return fmt.Errorf("invalid ipv6 address %q for network %s", n.IPv6Address, n.Name)
+			}
+		}
+	}
+
+	if h := c.Healthcheck; h != nil {
+		if len(h.Test) == 0 {
+			return fmt.Errorf("healthcheck test is required")
+		}
+		switch h.Test[0] {
+		case "NONE":
+		case "CMD", "CMD-SHELL":
+			if len(h.Test) < 2 {
+				return fmt.Errorf("healthcheck %s requires a command", h.Test[0])
+			}
+		default:
+			return fmt.Errorf("healthcheck test must start with NONE, CMD or CMD-SHELL")
+		}
+		// 与 Docker 一致，非 0 的时间不能小于 1ms
+		for name, d := range map[string]time.Duration{"interval": h.Interval, "timeout": h.Timeout, "start period": h.StartPeriod} {
+			if d != 0 && d < time.Millisecond {
+				return fmt.Errorf("healthcheck %s must be at least 1ms", name)
+			}
+		}
+		if h.Retries < 0 {
+			return fmt.Errorf("healthcheck retries must not be negative")
+		}
+	}
+
+	if err := c.Resources.validate(); err != nil {
+		return err
+	}
+
+	for _, d := range c.Devices {
+		if d.Host == "" {
+			return fmt.Errorf("device host path is required")
+		}
+		if strings.Trim(d.Permissions, "rwm") != "" {
+			return fmt.Errorf("invalid device permissions %q: must be a combination of r, w and m", d.Permissions)
+		}
+	}
+
+	for _, u := range c.Ulimits {
+		if u.Name == "" {
+			return fmt.Errorf("ulimit name is required")
+		}
+		if u.Soft > u.Hard {
+			return fmt.Errorf("ulimit %s: soft limit must not exceed hard limit", u.Name)
+		}
+	}
+
+	switch c.Pull {
+	case "", PullNever, PullMissing, PullAlways:
+	default:
+		return fmt.Errorf("invalid pull policy %q: must be never, missing or always", c.Pull)
+	}
+
+	if c.Platform != "" {
+		if _, err := parsePlatform(c.Platform); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateRestartPolicy(policy string, maxRetries int) error {
+	switch policy {
+	case "", "no", "always", "unless-stopped":
+		if maxRetries != 0 {
+			return fmt.Errorf("max retries is only supported by the on-failure restart policy")
+		}
+	case "on-failure":
+		if maxRetries < 0 {
+			return fmt.Errorf("max retries must not be negative")
+		}
+	default:
+		return fmt.Errorf("invalid restart policy %q", policy)
+	}
+	return nil
+}
+
+func (r Resources) validate() error {
+	if r.CPUs < 0 || r.CPUShares < 0 || r.Memory < 0 || r.MemoryReservation < 0 || r.PidsLimit < 0 {
+		return fmt.Errorf("resource limits must not be negative")
+	}
+	if r.MemorySwap < -1 {
+		return fmt.Errorf("memory swap must be -1 (unlimited) or a size in bytes")
+	}
+	if r.MemorySwap > 0 && (r.Memory == 0 || r.MemorySwap < r.Memory) {
+		return fmt.Errorf("memory swap must be greater than or equal to memory")
+	}
+	if r.MemoryReservation > 0 && r.Memory > 0 && r.MemoryReservation > r.Memory {
+		return fmt.Errorf("memory reservation must be less than or equal to memory")
+	}
+	return nil
+}
+
+// parsePlatform 解析 os[/arch[/variant]] 格式的平台
+func parsePlatform(value string) (*ocispec.Platform, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) > 3 {
+		return nil, fmt.Errorf("invalid platform %q: must be os[/arch[/variant]]", value)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("invalid platform %q: must be os[/arch[/variant]]", value)
+		}
+	}
+	p := &ocispec.Platform{OS: parts[0]}
+	if len(parts) > 1 {
+		p.Architecture = parts[1]
+	}
+	if len(parts) > 2 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
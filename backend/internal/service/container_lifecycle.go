@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// 等待容器的条件，与 docker wait 一致
+const (
+	WaitConditionNotRunning = "not-running"
+	WaitConditionNextExit   = "next-exit"
+	WaitConditionRemoved    = "removed"
+)
+
+// ContainerUpdate 原地修改容器的配置，为 0 或空的字段保持不变
+type ContainerUpdate struct {
+	Resources     Resources
+	RestartPolicy string
+	MaxRetries    int
+}
+
+// Validate 校验修改内容，至少需要修改一项
+func (u ContainerUpdate) Validate() error {
+	if u == (ContainerUpdate{}) {
+		return fmt.Errorf("nothing to update")
+	}
+	if err := u.Resources.validate(); err != nil {
+		return err
+	}
+	if u.RestartPolicy == "" && u.MaxRetries != 0 {
+		return fmt.Errorf("max retries requires the on-failure restart policy")
+	}
+	return validateRestartPolicy(u.RestartPolicy, u.MaxRetries)
+}
+
+// ContainerWaitResult 容器退出的结果
+type ContainerWaitResult struct {
+	StatusCode int64  `json:"statusCode"`
+	Error      string `json:"error,omitempty"`
+}
+
+func validateStopTimeout(timeout *int) error {
+	if timeout != nil && *timeout < -1 {
+		return fmt.Errorf("timeout must be -1 (wait forever) or a number of seconds")
+	}
+	return nil
+}
+
+// RestartContainer 重启容器，timeout 与 StopContainer 相同
+func (s *DockerService) RestartContainer(ctx context.Context, contextName string, id string, timeout *int) (err error) {
+	defer logCall(ctx, contextName, "ContainerRestart", time.Now(), &err)
+
+	if err := validateStopTimeout(timeout); err != nil {
+		return err
+	}
+	rt, release, err := s.getRuntime(contextName)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return rt.RestartContainer(ctx, id, timeout)
+}
+
+// KillContainer 向容器发送信号，signal 为空时发送 SIGKILL
+func (s *DockerService) KillContainer(ctx context.Context, contextName string, id string, signal string) (err error) {
+	defer logCall(ctx, contextName, "ContainerKill", time.Now(), &err)
+
+	rt, release, err := s.getRuntime(contextName)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return rt.KillContainer(ctx, id, signal)
+}
+
+func (s *DockerService) PauseContainer(ctx context.Context, contextName string, id string) (err error) {
+	defer logCall(ctx, contextName, "ContainerPause", time.Now(), &err)
+
+	rt, release, err := s.getRuntime(contextName)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return rt.PauseContainer(ctx, id)
+}
+
+func (s *DockerService) UnpauseContainer(ctx context.Context, contextName string, id string) (err error) {
+	defer logCall(ctx, contextName, "ContainerUnpause", time.Now(), &err)
+
+	rt, release, err := s.getRuntime(contextName)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return rt.UnpauseContainer(ctx, id)
+}
+
+func (s *DockerService) RenameContainer(ctx context.Context, contextName string, id string, name string) (err error) {
+	defer logCall(ctx, contextName, "ContainerRename", time.Now(), &err)
+
+	if !containerNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid container name %q", name)
+	}
+	rt, release, err := s.getRuntime(contextName)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return rt.RenameContainer(ctx, id, name)
+}
+
+// UpdateContainer 修改运行中容器的资源限制和重启策略，返回 daemon 的警告
+func (s *DockerService) UpdateContainer(ctx context.Context, contextName string, id string, update ContainerUpdate) (warnings []string, err error) {
+	defer logCall(ctx, contextName, "ContainerUpdate", time.Now(), &err)
+
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+	rt, release, err := s.getRuntime(contextName)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return rt.UpdateContainer(ctx, id, update)
+}
+
+// WaitContainer 阻塞直到容器满足条件，condition 为空时等待容器停止
+func (s *DockerService) WaitContainer(ctx context.Context, contextName string, id string, condition string) (result ContainerWaitResult, err error) {
+	defer logCall(ctx, contextName, "ContainerWait", time.Now(), &err)
+
+	switch condition {
+	case "":
+		condition = WaitConditionNotRunning
+	case WaitConditionNotRunning, WaitConditionNextExit, WaitConditionRemoved:
+	default:
+		return ContainerWaitResult{}, fmt.Errorf("invalid wait condition %q: must be not-running, next-exit or removed", condition)
+	}
+	rt, release, err := s.getRuntime(contextName)
+	if err != nil {
+		return ContainerWaitResult{}, err
+	}
+	defer release()
+	return rt.WaitContainer(ctx, id, condition)
+}
@@ -1,39 +1,56 @@
 package service
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"os"
-	"path/filepath"
+	"net"
 	"sort"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/docker/docker/api/types/volume"
 
 	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
-	"github.com/docker/go-connections/nat"
+
+	"github.com/smartcat999/container-ui/internal/logger"
+	"github.com/smartcat999/container-ui/internal/store"
 )
 
+// ErrNoCurrentContext 尚未设置当前 context
+var ErrNoCurrentContext = errors.New("no current context set")
+
+// DockerService 管理 context，并将容器操作交给 context 类型对应的运行时（Docker、Podman 或 containerd）
 type DockerService struct {
-	clients map[string]*client.Client // 存储多个 context 的 client
+	store store.Backend
+
+	// mu 保护 runtimes（含使用者计数）和 generations，gin 的 handler 会并发访问
+	mu       sync.Mutex
+	runtimes map[string]*cachedRuntime // 存储多个 context 的运行时
+	// generations 每个 context 的配置变化或缓存的运行时被移除时递增，用于识别创建期间已过期的运行时
+	generations map[string]uint64
+
+	health healthRegistry
+	// cliRegistryAuth 拉取镜像时未提交凭据则使用服务所在主机 docker login 保存的凭据。
+	// 这些凭据会发给连接的 daemon，而 daemon 可以是任意远程主机，因此默认关闭
+	cliRegistryAuth bool
+	// metrics 历史资源统计，MonitorMetrics 启动后才有数据
+	metrics metricsStore
 }
 
 type ContainerInfo struct {
-	ID      string `json:"id"`
-	Name    string `json:"name"`
-	Image   string `json:"image"`
-	Status  string `json:"status"`
-	State   string `json:"state"`
-	Created int64  `json:"created"`
-	Ports   []Port `json:"ports"`
+	ID      string            `json:"id"`
+	Name    string            `json:"name"`
+	Image   string            `json:"image"`
+	Status  string            `json:"status"`
+	State   string            `json:"state"`
+	Created int64             `json:"created"`
+	Ports   []Port            `json:"ports"`
+	Labels  map[string]string `json:"labels,omitempty"`
 }
 
 type Port struct {
@@ -44,11 +61,12 @@ type Port struct {
 }
 
 type ImageInfo struct {
-	ID         string `json:"id"`
-	Repository string `json:"repository"`
-	Tag        string `json:"tag"`
-	Size       int64  `json:"size"`
-	Created    int64  `json:"created"`
+	ID         string            `json:"id"`
+	Repository string            `json:"repository"`
+	Tag        string            `json:"tag"`
+	Size       int64             `json:"size"`
+	Created    int64             `json:"created"`
+	Labels     map[string]string `json:"labels,omitempty"`
 }
 
 type NetworkInfo struct {
@@ -73,47 +91,94 @@ type VolumeInfo struct {
 // ContextConfig 定义
 type ContextConfig struct {
 	Name    string `json:"name"`
-	Type    string `json:"type"` // tcp or socket
-	Host    string `json:"host"` // tcp://host:port 或 unix:///path/to/socket
+	Type    string `json:"type"` // tcp、socket、ssh、podman 或 containerd
+	Host    string `json:"host"` // tcp://host:port、unix:///path/to/socket 或 ssh://user@host
 	Current bool   `json:"current"`
+	// TLS 仅用于 tcp 类型，为空时使用明文连接
+	TLS *TLSConfig `json:"tls,omitempty"`
+	// SSH 仅用于 ssh 类型，host 格式为 ssh://user@host[:port]
+	SSH *SSHConfig `json:"ssh,omitempty"`
+	// Source 为 docker-cli 时表示从 Docker CLI 导入，同步时会随之更新，手动修改后不再同步
+	Source string `json:"source,omitempty"`
+	// Labels 标签，如 env=prod；Groups 所属分组。更新时为 null 表示保留原有值
+	Labels map[string]string `json:"labels,omitempty"`
+	Groups []string          `json:"groups,omitempty"`
+	// Namespace 仅用于 containerd 类型，为空时使用 default
+	Namespace string `json:"namespace,omitempty"`
+	// Health 最近一次健康检查的结果，未开启健康检查或尚未检查时为空
+	Health *ContextHealth `json:"health,omitempty"`
 }
 
-// 构建 Docker Host URL
-func buildDockerHost(config ContextConfig) string {
-	return config.Host
-}
-
-// 解析 Docker Host URL
-func parseDockerHost(hostURL string) (string, int, string) {
-	if strings.HasPrefix(hostURL, "tcp://") {
-		host := strings.TrimPrefix(hostURL, "tcp://")
-		parts := strings.Split(host, ":")
-		if len(parts) == 2 {
-			port, _ := strconv.Atoi(parts[1])
-			return parts[0], port, ""
-		}
-		return host, 2375, ""
+// normalizeTCPHost 为未指定端口的 tcp 地址补全 Docker 默认端口，TLS 为 2376，明文为 2375
+func normalizeTCPHost(host string, useTLS bool) string {
+	if !strings.HasPrefix(host, "tcp://") {
+		return host
+	}
+	addr := strings.TrimPrefix(host, "tcp://")
+	if _, _, err := net.SplitHostPort(addr); err == nil {
+		return host
 	}
-	return "", 0, strings.TrimPrefix(hostURL, "unix://")
+	port := "2375"
+	if useTLS {
+		port = "2376"
+	}
+	return "tcp://" + net.JoinHostPort(strings.Trim(addr, "[]"), port)
 }
 
 // ContainerConfig 容器配置
 type ContainerConfig struct {
-	ImageID       string
-	Name          string
-	Command       string
-	Args          []string
-	Ports         []PortMapping
-	Env           []EnvVar
-	Volumes       []VolumeMapping
+	ImageID string
+	Name    string
+	// Command 和 Args 覆盖镜像的 CMD，Entrypoint 覆盖镜像的 ENTRYPOINT
+	Command    string
+	Args       []string
+	Entrypoint []string
+	WorkingDir string
+	// User 用户名或 uid[:gid]
+	User    string
+	Labels  map[string]string
+	Ports   []PortMapping
+	Env     []EnvVar
+	Volumes []VolumeMapping
+	// RestartPolicy 为 no、on-failure、always 或 unless-stopped，MaxRetries 仅用于 on-failure
 	RestartPolicy string
-	NetworkMode   string
+	MaxRetries    int
+	// NetworkMode 为 bridge、host、none 或网络名；指定 Networks 时可省略，默认使用第一个网络
+	NetworkMode string
+	Networks    []NetworkAttachment
+	Healthcheck *Healthcheck
+	Resources   Resources
+	CapAdd      []string
+	CapDrop     []string
+	Devices     []DeviceMapping
+	Ulimits     []Ulimit
+	LogDriver   string
+	LogOptions  map[string]string
+	// Platform 镜像平台，如 linux/arm64，为空时由 daemon 决定
+	Platform string
+	// Start 为 true 时创建后立即启动，启动失败会删除刚创建的容器
+	Start bool
+	// Pull 镜像拉取策略：never（默认）、missing 或 always；RegistryAuth 为空时不带凭据拉取（见 SetCLIRegistryAuth）
+	Pull         string
+	RegistryAuth *RegistryAuth
+}
+
+// CreateContainerResult 创建容器的结果
+type CreateContainerResult struct {
+	ID string `json:"id"`
+	// Warnings daemon 返回的警告，如内存限制不生效
+	Warnings []string `json:"warnings"`
+	Started  bool     `json:"started"`
 }
 
 // PortMapping 端口映射
 type PortMapping struct {
 	Host      uint16
 	Container uint16
+	// Protocol 为 tcp（默认）、udp 或 sctp
+	Protocol string
+	// HostIP 绑定的主机地址，默认 0.0.0.0
+	HostIP string
 }
 
 // EnvVar 环境变量
@@ -122,517 +187,571 @@ type EnvVar struct {
 	Value string
 }
 
-// VolumeMapping 数据卷映射
+// 数据卷类型
+const (
+	VolumeTypeBind   = "bind"
+	VolumeTypeVolume = "volume"
+	VolumeTypeTmpfs  = "tmpfs"
+)
+
+// VolumeMapping 数据卷映射。
+// Type 为 bind（默认）时 Host 为主机路径，为 volume 时 Host 为数据卷名，为 tmpfs 时忽略 Host
 type VolumeMapping struct {
+	Type      string
 	Host      string
 	Container string
-	Mode      string
+	// Mode 为 rw 或 ro，bind 类型也可以带其他选项，如 ro,z
+	Mode string
+	// TmpfsSize tmpfs 的大小上限，单位字节，0 表示不限制
+	TmpfsSize int64
+}
+
+// NetworkAttachment 容器连接的网络
+type NetworkAttachment struct {
+	Name        string
+	Aliases     []string
+	IPv4Address string
+	IPv6Address string
+}
+
+// Healthcheck 健康检查，Test 与 Dockerfile 的 HEALTHCHECK 相同，如 ["CMD-SHELL", "curl -f http://localhost/"]，["NONE"] 表示禁用镜像中的健康检查
+type Healthcheck struct {
+	Test        []string
+	Interval    time.Duration
+	Timeout     time.Duration
+	StartPeriod time.Duration
+	Retries     int
+}
+
+// Resources 资源限制，为 0 表示不限制
+type Resources struct {
+	// CPUs CPU 核数，可以为小数，如 0.5
+	CPUs      float64
+	CPUShares int64
+	// Memory、MemoryReservation 和 MemorySwap 单位为字节，MemorySwap 为 -1 表示不限制 swap
+	Memory            int64
+	MemoryReservation int64
+	MemorySwap        int64
+	PidsLimit         int64
+}
+
+// DeviceMapping 映射到容器中的设备
+type DeviceMapping struct {
+	Host      string
+	Container string
+	// Permissions cgroup 权限，默认 rwm
+	Permissions string
 }
 
-const (
-	configDir  = ".docker-contexts"
-	configFile = "contexts.json"
-)
-
-// 获取配置文件路径
-func getConfigPath() string {
-	dir := filepath.Join(".", configDir)
-
-	// 确保配置目录存在
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			// 如果无法创建目录，使用当前目录
-			return filepath.Join(".", configFile)
-		}
-	}
-
-	return filepath.Join(dir, configFile)
+// Ulimit 容器进程的资源限制，如 nofile
+type Ulimit struct {
+	Name string
+	Soft int64
+	Hard int64
 }
 
-// 读取配置
-func readConfig() (map[string]interface{}, error) {
-	configPath := getConfigPath()
-
-	// 如果文件不存在，创建默认配置
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		defaultConfig := map[string]interface{}{
-			"contexts":        make(map[string]interface{}),
-			"current-context": "",
-		}
-		data, err := json.MarshalIndent(defaultConfig, "", "  ")
-		if err != nil {
-			return nil, err
-		}
-		if err := os.WriteFile(configPath, data, 0644); err != nil {
-			return nil, err
-		}
-		return defaultConfig, nil
-	}
-
-	// 读取现有配置
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, err
-	}
-
-	var config map[string]interface{}
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, err
-	}
-
-	return config, nil
+// NewDockerService 创建 Docker 服务，context 配置及其证书和私钥保存在 backend 中
+func NewDockerService(backend store.Backend) (*DockerService, error) {
+	return &DockerService{
+		store:       backend,
+		runtimes:    make(map[string]*cachedRuntime),
+		generations: make(map[string]uint64),
+	}, nil
 }
 
-// 保存配置
-func saveConfig(config map[string]interface{}) error {
-	configPath := getConfigPath()
+// SetCLIRegistryAuth 设置拉取镜像时未提交凭据是否使用服务所在主机 docker login 保存的凭据，在启动时调用
+func (s *DockerService) SetCLIRegistryAuth(enabled bool) {
+	s.cliRegistryAuth = enabled
+}
 
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return err
+// logCall 记录一次 Docker API 调用，日志携带请求 ID 以便与 HTTP 请求关联
+func logCall(ctx context.Context, contextName, op string, start time.Time, err *error) {
+	l := logger.FromContext(ctx).With(
+		"context", contextName,
+		"op", op,
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+	if *err != nil {
+		l.Warn("docker api call failed", "error", *err)
+		return
 	}
-
-	return os.WriteFile(configPath, data, 0644)
+	l.Debug("docker api call")
 }
 
-func NewDockerService() (*DockerService, error) {
-	return &DockerService{
-		clients: make(map[string]*client.Client),
-	}, nil
+// cachedRuntime 缓存的运行时及其使用者数量，字段由 DockerService.mu 保护
+type cachedRuntime struct {
+	Runtime
+	refs int
+	// evicted 已从缓存中移除，最后一个使用者释放时关闭
+	evicted bool
+	closed  bool
 }
 
-// getClient 根据 context name 获取或创建对应的 Docker client
-func (s *DockerService) getClient(contextName string) (*client.Client, error) {
-	// 检查是否已有该 context 的 client
-	if cli, exists := s.clients[contextName]; exists {
-		return cli, nil
+// getRuntime 根据 context name 获取或创建对应的运行时，使用完后需调用 release。
+// 日志跟随、资源统计推送等长时间的调用在 release 之前持有运行时，健康检查丢弃运行时不会中断它们
+func (s *DockerService) getRuntime(contextName string) (Runtime, func(), error) {
+	// 检查是否已有该 context 的运行时
+	s.mu.Lock()
+	cached, exists := s.runtimes[contextName]
+	generation := s.generations[contextName]
+	if exists {
+		cached.refs++
+		s.mu.Unlock()
+		return cached.Runtime, s.releaser(cached), nil
 	}
+	s.mu.Unlock()
 
-	// 读取 context 配置
-	config, err := readConfig()
+	// 读取 context 配置，读取和创建运行时时不持有锁
+	data, err := s.store.LoadContexts()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-
-	contexts, ok := config["contexts"].(map[string]interface{})
+	stored, ok := data.Contexts[contextName]
 	if !ok {
-		return nil, fmt.Errorf("no contexts found")
+		return nil, nil, fmt.Errorf("context %s not found", contextName)
 	}
-
-	contextConfig, ok := contexts[contextName].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("context %s not found", contextName)
+	if stored.Host == "" {
+		return nil, nil, fmt.Errorf("invalid host configuration for context %s", contextName)
 	}
-
-	host, _ := contextConfig["host"].(string)
-	if host == "" {
-		return nil, fmt.Errorf("invalid host configuration for context %s", contextName)
-	}
-
-	// 创建新的 client
-	cli, err := client.NewClientWithOpts(
-		client.WithHost(host),
-		client.WithAPIVersionNegotiation(),
-	)
+	rt, err := s.newRuntime(contextName, toRuntimeConfig(contextName, stored))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create docker client: %v", err)
+		return nil, nil, err
 	}
 
-	// 保存 client
-	s.clients[contextName] = cli
-	return cli, nil
-}
-
-func (s *DockerService) ListContainers(contextName string) ([]ContainerInfo, error) {
-	cli, err := s.getClient(contextName)
-	if err != nil {
-		return nil, err
+	s.mu.Lock()
+	existing, exists := s.runtimes[contextName]
+	stale := s.generations[contextName] != generation
+	if !exists && !stale {
+		// 保存运行时
+		cached = &cachedRuntime{Runtime: rt, refs: 1}
+		s.runtimes[contextName] = cached
+		s.mu.Unlock()
+		return rt, s.releaser(cached), nil
 	}
-
-	containers, err := cli.ContainerList(context.Background(), types.ContainerListOptions{All: true})
-	if err != nil {
-		return nil, err
+	if exists && !stale {
+		existing.refs++
 	}
+	s.mu.Unlock()
 
-	var containerInfos []ContainerInfo
-	for _, container := range containers {
-		// 处理容器名称，移除开头的 "/"
-		name := strings.TrimPrefix(container.Names[0], "/")
-
-		// 转换端口信息
-		var ports []Port
-		for _, p := range container.Ports {
-			ports = append(ports, Port{
-				IP:          p.IP,
-				PrivatePort: p.PrivatePort,
-				PublicPort:  p.PublicPort,
-				Type:        p.Type,
-			})
-		}
+	// 其他请求已创建了运行时，或创建期间配置已被修改
+	rt.Close()
+	if exists && !stale {
+		return existing.Runtime, s.releaser(existing), nil
+	}
+	return s.getRuntime(contextName)
+}
 
-		containerInfos = append(containerInfos, ContainerInfo{
-			ID:      container.ID[:12], // 只显示ID的前12位
-			Name:    name,
-			Image:   container.Image,
-			Status:  container.Status,
-			State:   container.State,
-			Created: container.Created,
-			Ports:   ports,
+// releaser 返回释放运行时的函数，重复调用无效
+func (s *DockerService) releaser(cached *cachedRuntime) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			cached.refs--
+			closeNow := cached.evicted && !cached.closed && cached.refs == 0
+			if closeNow {
+				cached.closed = true
+			}
+			s.mu.Unlock()
+			if closeNow {
+				cached.Close()
+			}
 		})
 	}
+}
 
-	return containerInfos, nil
+// releasingConn 关闭时释放运行时的连接
+type releasingConn struct {
+	io.ReadWriteCloser
+	release func()
 }
 
-func (s *DockerService) StartContainer(contextName string, id string) error {
-	cli, err := s.getClient(contextName)
-	if err != nil {
-		return err
-	}
-	return cli.ContainerStart(context.Background(), id, types.ContainerStartOptions{})
+func (c *releasingConn) Close() error {
+	err := c.ReadWriteCloser.Close()
+	c.release()
+	return err
 }
 
-func (s *DockerService) StopContainer(contextName string, id string) error {
-	cli, err := s.getClient(contextName)
-	if err != nil {
-		return err
+// newDockerClient 按 context 配置创建 Docker client，ssh 类型同时返回其 SSH 通道
+func (s *DockerService) newDockerClient(contextName string, config ContextConfig) (*client.Client, *sshTunnel, error) {
+	var opts []client.Opt
+	var tunnel *sshTunnel
+	if strings.HasPrefix(config.Host, "ssh://") {
+		// 请求经 SSH 通道转发到远端 docker.sock，host 仅用于构造请求 URL
+		var err error
+		tunnel, err = newSSHTunnel(contextName, config.Host, config.SSH)
+		if err != nil {
+			return nil, nil, err
+		}
+		opts = append(opts,
+			client.WithHost("http://docker.example.com"),
+			client.WithDialContext(tunnel.DialContext),
+		)
+	} else {
+		// TLS 选项会替换 HTTP client，必须在 WithHost 之前设置
+		if config.TLS != nil {
+			tlsOpt, err := tlsClientOpt(contextName, config.TLS)
+			if err != nil {
+				return nil, nil, err
+			}
+			opts = append(opts, tlsOpt)
+		}
+		opts = append(opts, client.WithHost(normalizeTCPHost(config.Host, config.TLS != nil)))
 	}
-	return cli.ContainerStop(context.Background(), id, container.StopOptions{})
-}
+	opts = append(opts, client.WithAPIVersionNegotiation())
 
-func (s *DockerService) GetContainerDetail(contextName string, id string) (types.ContainerJSON, error) {
-	cli, err := s.getClient(contextName)
+	// 创建新的 client
+	cli, err := client.NewClientWithOpts(opts...)
 	if err != nil {
-		return types.ContainerJSON{}, err
+		return nil, nil, fmt.Errorf("failed to create docker client: %v", err)
 	}
-	return cli.ContainerInspect(context.Background(), id)
+	return cli, tunnel, nil
 }
 
-func (s *DockerService) ListImages(contextName string) ([]ImageInfo, error) {
-	cli, err := s.getClient(contextName)
-	if err != nil {
-		return nil, err
-	}
+// dropRuntime 丢弃缓存的运行时并立即关闭其连接，用于配置变化，下次使用时按最新配置重建。
+// 即使没有缓存也会使正在按旧配置创建的运行时失效
+func (s *DockerService) dropRuntime(contextName string) {
+	s.removeRuntime(contextName, true)
+}
 
-	images, err := cli.ImageList(context.Background(), types.ImageListOptions{All: true})
-	if err != nil {
-		return nil, err
-	}
+// evictRuntime 从缓存中移除运行时，但不中断正在使用它的调用，最后一个使用者释放时关闭
+func (s *DockerService) evictRuntime(contextName string) {
+	s.removeRuntime(contextName, false)
+}
 
-	var imageInfos []ImageInfo
-	for _, image := range images {
-		// 处理 RepoTags，可能为空
-		repository := "<none>"
-		tag := "<none>"
-		if len(image.RepoTags) > 0 {
-			parts := strings.Split(image.RepoTags[0], ":")
-			if len(parts) == 2 {
-				repository = parts[0]
-				tag = parts[1]
-			}
+// removeRuntime 移除 context 的运行时。configChanged 为 true 时立即关闭，
+// 并且无论是否有缓存都递增 generation；否则只在确实移除了运行时时递增
+func (s *DockerService) removeRuntime(contextName string, configChanged bool) {
+	closeNow := configChanged
+	s.mu.Lock()
+	cached := s.runtimes[contextName]
+	delete(s.runtimes, contextName)
+	if configChanged || cached != nil {
+		s.generations[contextName]++
+	}
+	if cached != nil {
+		cached.evicted = true
+		closeNow = (closeNow || cached.refs == 0) && !cached.closed
+		if closeNow {
+			cached.closed = true
 		}
-
-		imageInfos = append(imageInfos, ImageInfo{
-			ID:         image.ID[7:19], // 移除 "sha256:" 前缀并截取
-			Repository: repository,
-			Tag:        tag,
-			Size:       image.Size,
-			Created:    image.Created,
-		})
 	}
+	s.mu.Unlock()
 
-	return imageInfos, nil
-}
-
-func (s *DockerService) DeleteImage(contextName string, id string) error {
-	cli, err := s.getClient(contextName)
-	if err != nil {
-		return err
+	if cached != nil && closeNow {
+		cached.Close()
 	}
-	_, err = cli.ImageRemove(context.Background(), id, types.ImageRemoveOptions{Force: false})
-	return err
 }
 
-func (s *DockerService) CreateContainer(contextName string, config ContainerConfig) error {
-	cli, err := s.getClient(contextName)
-	if err != nil {
-		return err
+// newStoredContext 校验接口提交的配置并生成保存的 context，证书和私钥一并保存。
+// existing 为已保存的 context，提交的证书和私钥留空时保留其中的内容；为空时完全使用 config 中的内容
+func newStoredContext(config ContextConfig, existing *store.Context) (*store.Context, error) {
+	stored := &store.Context{
+		Type:   config.Type,
+		Host:   config.Host,
+		Source: config.Source,
+		Labels: config.Labels,
+		Groups: config.Groups,
 	}
-
-	// 准备端口绑定
-	portBindings := nat.PortMap{}
-	exposedPorts := nat.PortSet{}
-	if len(config.Ports) > 0 {
-		for _, p := range config.Ports {
-			containerPort := nat.Port(fmt.Sprintf("%d/tcp", p.Container))
-			hostBinding := nat.PortBinding{
-				HostIP:   "0.0.0.0",
-				HostPort: fmt.Sprintf("%d", p.Host),
+	if config.Type == ContextTypeContainerd {
+		stored.Namespace = config.Namespace
+	}
+	if config.TLS != nil {
+		if err := validateTLSMaterial(config.TLS); err != nil {
+			return nil, err
+		}
+		stored.TLS = &store.TLS{
+			Verify: config.TLS.Verify,
+			CA:     config.TLS.CA,
+			Cert:   config.TLS.Cert,
+			Key:    config.TLS.Key,
+		}
+		if existing != nil && existing.TLS != nil {
+			if stored.TLS.CA == "" {
+				stored.TLS.CA = existing.TLS.CA
+			}
+			if stored.TLS.Cert == "" {
+				stored.TLS.Cert = existing.TLS.Cert
+				stored.TLS.Key = existing.TLS.Key
 			}
-			portBindings[containerPort] = []nat.PortBinding{hostBinding}
-			exposedPorts[containerPort] = struct{}{}
 		}
 	}
-
-	// 准备环境变量
-	var env []string
-	if len(config.Env) > 0 {
-		env = make([]string, len(config.Env))
-		for i, e := range config.Env {
-			env[i] = fmt.Sprintf("%s=%s", e.Key, e.Value)
+	if config.SSH != nil {
+		if err := validateSSHConfig(config.SSH); err != nil {
+			return nil, err
 		}
-	}
-
-	// 准备数据卷
-	var binds []string
-	if len(config.Volumes) > 0 {
-		binds = make([]string, len(config.Volumes))
-		for i, v := range config.Volumes {
-			binds[i] = fmt.Sprintf("%s:%s:%s", v.Host, v.Container, v.Mode)
+		stored.SSH = &store.SSH{
+			KnownHosts: config.SSH.KnownHosts,
+			JumpHosts:  config.SSH.JumpHosts,
+			SocketPath: config.SSH.SocketPath,
+			PrivateKey: config.SSH.PrivateKey,
+		}
+		if existing != nil && existing.SSH != nil && stored.SSH.PrivateKey == "" {
+			stored.SSH.PrivateKey = existing.SSH.PrivateKey
 		}
 	}
-
-	// 准备重启策略
-	var restartPolicy container.RestartPolicy
-	switch config.RestartPolicy {
-	case "always":
-		restartPolicy = container.RestartPolicy{Name: "always"}
-	case "unless-stopped":
-		restartPolicy = container.RestartPolicy{Name: "unless-stopped"}
-	case "on-failure":
-		restartPolicy = container.RestartPolicy{Name: "on-failure"}
-	default:
-		restartPolicy = container.RestartPolicy{Name: "no"}
-	}
-
-	// 准备命令和参数
-	var cmd []string
-	if config.Command != "" {
-		cmd = append(cmd, config.Command)
-		if len(config.Args) > 0 {
-			cmd = append(cmd, config.Args...)
+	return stored, nil
+}
+
+// toContextConfig 将保存的 context 转换为接口返回的配置，不含证书和私钥
+func toContextConfig(name string, stored *store.Context, current bool) ContextConfig {
+	config := ContextConfig{
+		Name:      name,
+		Type:      stored.Type,
+		Host:      stored.Host,
+		Current:   current,
+		Source:    stored.Source,
+		Labels:    stored.Labels,
+		Groups:    stored.Groups,
+		Namespace: stored.Namespace,
+	}
+	if stored.TLS != nil {
+		config.TLS = &TLSConfig{Verify: stored.TLS.Verify}
+	}
+	if stored.SSH != nil {
+		config.SSH = &SSHConfig{
+			KnownHosts: stored.SSH.KnownHosts,
+			JumpHosts:  stored.SSH.JumpHosts,
+			SocketPath: stored.SSH.SocketPath,
 		}
 	}
+	return config
+}
 
-	// 创建容器配置
-	containerConfig := &container.Config{
-		Image: config.ImageID,
+// toRuntimeConfig 将保存的 context 转换为创建运行时使用的配置，包含证书和私钥
+func toRuntimeConfig(name string, stored *store.Context) ContextConfig {
+	config := toContextConfig(name, stored, false)
+	if stored.TLS != nil {
+		config.TLS.CA = stored.TLS.CA
+		config.TLS.Cert = stored.TLS.Cert
+		config.TLS.Key = stored.TLS.Key
 	}
-
-	// 只有在有命令时才设置
-	if len(cmd) > 0 {
-		containerConfig.Cmd = cmd
-	}
-
-	// 只有在有端口时才设置
-	if len(exposedPorts) > 0 {
-		containerConfig.ExposedPorts = exposedPorts
+	if stored.SSH != nil {
+		config.SSH.PrivateKey = stored.SSH.PrivateKey
 	}
+	return config
+}
 
-	// 只有在有环境变量时才设置
-	if len(env) > 0 {
-		containerConfig.Env = env
-	}
+// ListContainers 按 opts 筛选、排序并分页列出容器
+func (s *DockerService) ListContainers(ctx context.Context, contextName string, opts ListOptions) (page *ListPage[ContainerInfo], err error) {
+	defer logCall(ctx, contextName, "ContainerList", time.Now(), &err)
 
-	// 主机配置
-	hostConfig := &container.HostConfig{
-		RestartPolicy: restartPolicy,
+	if err := opts.Validate(ResourceContainer); err != nil {
+		return nil, err
 	}
-
-	// 只有在有端口映射时才设置
-	if len(portBindings) > 0 {
-		hostConfig.PortBindings = portBindings
+	rt, release, err := s.getRuntime(contextName)
+	if err != nil {
+		return nil, err
 	}
-
-	// 只有在有数据卷时才设置
-	if len(binds) > 0 {
-		hostConfig.Binds = binds
+	defer release()
+	containers, err := rt.ListContainers(ctx, opts.Filter)
+	if err != nil {
+		return nil, err
 	}
+	containers = filterLabels(containers, opts.Filter.Labels, func(c ContainerInfo) map[string]string { return c.Labels })
+	return paginate(containers, opts, containerSortKey)
+}
 
-	// 只有在指定网络模式时才设置
-	if config.NetworkMode != "" {
-		hostConfig.NetworkMode = container.NetworkMode(config.NetworkMode)
-	}
+func (s *DockerService) StartContainer(ctx context.Context, contextName string, id string) (err error) {
+	defer logCall(ctx, contextName, "ContainerStart", time.Now(), &err)
 
-	// 创建容器
-	resp, err := cli.ContainerCreate(
-		context.Background(),
-		containerConfig,
-		hostConfig,
-		nil,         // 网络配置，使用默认值
-		nil,         // 平台配置，使用默认值
-		config.Name, // 如果名称为空，Docker 会自动生成
-	)
+	rt, release, err := s.getRuntime(contextName)
 	if err != nil {
-		return fmt.Errorf("failed to create container: %v", err)
-	}
-
-	// 启动容器
-	if err := cli.ContainerStart(context.Background(), resp.ID, types.ContainerStartOptions{}); err != nil {
-		return fmt.Errorf("failed to start container: %v", err)
+		return err
 	}
-
-	return nil
+	defer release()
+	return rt.StartContainer(ctx, id)
 }
 
-func (s *DockerService) GetImageDetail(contextName string, id string) (types.ImageInspect, error) {
-	cli, err := s.getClient(contextName)
+// StopContainer 停止容器，timeout 为等待容器退出的秒数，超时后强制停止；-1 表示一直等待，nil 使用默认值
+func (s *DockerService) StopContainer(ctx context.Context, contextName string, id string, timeout *int) (err error) {
+	defer logCall(ctx, contextName, "ContainerStop", time.Now(), &err)
+
+	if err := validateStopTimeout(timeout); err != nil {
+		return err
+	}
+	rt, release, err := s.getRuntime(contextName)
 	if err != nil {
-		return types.ImageInspect{}, err
+		return err
 	}
-	inspect, _, err := cli.ImageInspectWithRaw(context.Background(), id)
+	defer release()
+	return rt.StopContainer(ctx, id, timeout)
+}
+
+func (s *DockerService) GetContainerDetail(ctx context.Context, contextName string, id string) (detail types.ContainerJSON, err error) {
+	defer logCall(ctx, contextName, "ContainerInspect", time.Now(), &err)
+
+	rt, release, err := s.getRuntime(contextName)
 	if err != nil {
-		return types.ImageInspect{}, err
+		return types.ContainerJSON{}, err
 	}
-	return inspect, nil
+	defer release()
+	return rt.GetContainerDetail(ctx, id)
 }
 
-func (s *DockerService) ListNetworks(contextName string) ([]NetworkInfo, error) {
-	cli, err := s.getClient(contextName)
+// ListImages 按 opts 筛选、排序并分页列出镜像
+func (s *DockerService) ListImages(ctx context.Context, contextName string, opts ListOptions) (page *ListPage[ImageInfo], err error) {
+	defer logCall(ctx, contextName, "ImageList", time.Now(), &err)
+
+	if err := opts.Validate(ResourceImage); err != nil {
+		return nil, err
+	}
+	rt, release, err := s.getRuntime(contextName)
 	if err != nil {
 		return nil, err
 	}
-
-	networks, err := cli.NetworkList(context.Background(), types.NetworkListOptions{})
+	defer release()
+	images, err := rt.ListImages(ctx, opts.Filter)
 	if err != nil {
 		return nil, err
 	}
+	images = filterLabels(images, opts.Filter.Labels, func(i ImageInfo) map[string]string { return i.Labels })
+	return paginate(images, opts, imageSortKey)
+}
 
-	var networkInfos []NetworkInfo
-	for _, network := range networks {
-		networkInfos = append(networkInfos, NetworkInfo{
-			ID:      network.ID,
-			Name:    network.Name,
-			Driver:  network.Driver,
-			Scope:   network.Scope,
-			IPAM:    network.IPAM,
-			Created: network.Created,
-		})
-	}
+func (s *DockerService) DeleteImage(ctx context.Context, contextName string, id string) (err error) {
+	defer logCall(ctx, contextName, "ImageRemove", time.Now(), &err)
 
-	return networkInfos, nil
+	rt, release, err := s.getRuntime(contextName)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return rt.DeleteImage(ctx, id)
 }
 
-func (s *DockerService) GetNetworkDetail(contextName string, id string) (types.NetworkResource, error) {
-	cli, err := s.getClient(contextName)
+// CreateContainer 创建容器，config.Start 为 true 时同时启动，需要拉取镜像时通过 progress 报告进度。
+// 出错时若容器已创建且未能回滚，result.ID 为残留容器的 ID
+func (s *DockerService) CreateContainer(ctx context.Context, contextName string, config ContainerConfig, progress func(PullProgress)) (result CreateContainerResult, err error) {
+	defer logCall(ctx, contextName, "ContainerCreate", time.Now(), &err)
+
+	if err := config.Validate(); err != nil {
+		return CreateContainerResult{}, err
+	}
+	rt, release, err := s.getRuntime(contextName)
 	if err != nil {
-		return types.NetworkResource{}, err
+		return CreateContainerResult{}, err
+	}
+	defer release()
+	if err := s.ensureImage(ctx, rt, config, progress); err != nil {
+		return CreateContainerResult{}, fmt.Errorf("failed to pull image %s: %w", config.ImageID, err)
 	}
-	return cli.NetworkInspect(context.Background(), id, types.NetworkInspectOptions{})
+	return rt.CreateContainer(ctx, config)
 }
 
-func (s *DockerService) DeleteNetwork(contextName string, id string) error {
-	cli, err := s.getClient(contextName)
+func (s *DockerService) GetImageDetail(ctx context.Context, contextName string, id string) (inspect types.ImageInspect, err error) {
+	defer logCall(ctx, contextName, "ImageInspect", time.Now(), &err)
+
+	rt, release, err := s.getRuntime(contextName)
 	if err != nil {
-		return err
+		return types.ImageInspect{}, err
 	}
-	return cli.NetworkRemove(context.Background(), id)
+	defer release()
+	return rt.GetImageDetail(ctx, id)
 }
 
-func (s *DockerService) ListVolumes(contextName string) ([]VolumeInfo, error) {
-	cli, err := s.getClient(contextName)
-	if err != nil {
+// ListNetworks 按 opts 筛选、排序并分页列出网络。
+// 网络列表不包含标签，key!=value 形式的标签条件不生效
+func (s *DockerService) ListNetworks(ctx context.Context, contextName string, opts ListOptions) (page *ListPage[NetworkInfo], err error) {
+	defer logCall(ctx, contextName, "NetworkList", time.Now(), &err)
+
+	if err := opts.Validate(ResourceNetwork); err != nil {
 		return nil, err
 	}
-
-	volumes, err := cli.VolumeList(context.Background(), volume.ListOptions{})
+	rt, release, err := s.getRuntime(contextName)
 	if err != nil {
 		return nil, err
 	}
-
-	var volumeInfos []VolumeInfo
-	for _, volume := range volumes.Volumes {
-		volumeInfos = append(volumeInfos, VolumeInfo{
-			Name:       volume.Name,
-			Driver:     volume.Driver,
-			Mountpoint: volume.Mountpoint,
-			CreatedAt:  volume.CreatedAt,
-			Labels:     volume.Labels,
-			Scope:      volume.Scope,
-			Options:    volume.Options,
-		})
+	defer release()
+	networks, err := rt.ListNetworks(ctx, opts.Filter)
+	if err != nil {
+		return nil, err
 	}
-
-	return volumeInfos, nil
+	return paginate(networks, opts, networkSortKey)
 }
 
-func (s *DockerService) GetVolumeDetail(contextName string, name string) (volume.Volume, error) {
-	cli, err := s.getClient(contextName)
+func (s *DockerService) GetNetworkDetail(ctx context.Context, contextName string, id string) (detail types.NetworkResource, err error) {
+	defer logCall(ctx, contextName, "NetworkInspect", time.Now(), &err)
+
+	rt, release, err := s.getRuntime(contextName)
 	if err != nil {
-		return volume.Volume{}, err
+		return types.NetworkResource{}, err
 	}
-	return cli.VolumeInspect(context.Background(), name)
+	defer release()
+	return rt.GetNetworkDetail(ctx, id)
 }
 
-func (s *DockerService) DeleteVolume(contextName string, name string) error {
-	cli, err := s.getClient(contextName)
+func (s *DockerService) DeleteNetwork(ctx context.Context, contextName string, id string) (err error) {
+	defer logCall(ctx, contextName, "NetworkRemove", time.Now(), &err)
+
+	rt, release, err := s.getRuntime(contextName)
 	if err != nil {
 		return err
 	}
-	return cli.VolumeRemove(context.Background(), name, true)
+	defer release()
+	return rt.DeleteNetwork(ctx, id)
 }
 
-func (s *DockerService) GetContainerLogs(contextName string, id string) (string, error) {
-	cli, err := s.getClient(contextName)
+// ListVolumes 按 opts 筛选、排序并分页列出数据卷
+func (s *DockerService) ListVolumes(ctx context.Context, contextName string, opts ListOptions) (page *ListPage[VolumeInfo], err error) {
+	defer logCall(ctx, contextName, "VolumeList", time.Now(), &err)
+
+	if err := opts.Validate(ResourceVolume); err != nil {
+		return nil, err
+	}
+	rt, release, err := s.getRuntime(contextName)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-
-	options := types.ContainerLogsOptions{
-		ShowStdout: true,
-		ShowStderr: true,
-		Timestamps: true,
-		Tail:       "1000", // 获取最后1000行日志
+	defer release()
+	volumes, err := rt.ListVolumes(ctx, opts.Filter)
+	if err != nil {
+		return nil, err
 	}
+	volumes = filterLabels(volumes, opts.Filter.Labels, func(v VolumeInfo) map[string]string { return v.Labels })
+	return paginate(volumes, opts, volumeSortKey)
+}
+
+func (s *DockerService) GetVolumeDetail(ctx context.Context, contextName string, name string) (detail volume.Volume, err error) {
+	defer logCall(ctx, contextName, "VolumeInspect", time.Now(), &err)
 
-	logs, err := cli.ContainerLogs(context.Background(), id, options)
+	rt, release, err := s.getRuntime(contextName)
 	if err != nil {
-		return "", err
+		return volume.Volume{}, err
 	}
-	defer logs.Close()
+	defer release()
+	return rt.GetVolumeDetail(ctx, name)
+}
+
+func (s *DockerService) DeleteVolume(ctx context.Context, contextName string, name string) (err error) {
+	defer logCall(ctx, contextName, "VolumeRemove", time.Now(), &err)
 
-	// 读取日志内容
-	buf := new(bytes.Buffer)
-	_, err = buf.ReadFrom(logs)
+	rt, release, err := s.getRuntime(contextName)
 	if err != nil {
-		return "", err
+		return err
 	}
-
-	return buf.String(), nil
+	defer release()
+	return rt.DeleteVolume(ctx, name)
 }
 
-func (s *DockerService) ListContexts() ([]ContextConfig, error) {
-	config, err := readConfig()
+// ListContexts 列出满足 selector 的 context，当前 context 排在最前
+func (s *DockerService) ListContexts(selector ContextSelector) ([]ContextConfig, error) {
+	data, err := s.store.LoadContexts()
 	if err != nil {
 		return nil, err
 	}
 
-	currentCtx, _ := config["current-context"].(string)
-	contextsMap, ok := config["contexts"].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("no contexts found")
-	}
-
 	var contextConfigs []ContextConfig
 	var currentConfig *ContextConfig
 
-	for name, ctx := range contextsMap {
-		contextConfig, ok := ctx.(map[string]interface{})
-		if !ok {
+	for name, stored := range data.Contexts {
+		if !selector.Matches(name, stored) {
 			continue
 		}
+		config := toContextConfig(name, stored, name == data.CurrentContext)
+		config.Health = s.health.get(name)
 
-		contextType, _ := contextConfig["type"].(string)
-		host, _ := contextConfig["host"].(string)
-
-		config := ContextConfig{
-			Name:    name,
-			Type:    contextType,
-			Host:    host,
-			Current: name == currentCtx,
-		}
-
-		if name == currentCtx {
+		if config.Current {
 			currentConfig = &config
 		} else {
 			contextConfigs = append(contextConfigs, config)
@@ -654,185 +773,218 @@ func (s *DockerService) ListContexts() ([]ContextConfig, error) {
 
 func (s *DockerService) CreateContext(config ContextConfig) error {
 	// 创建 context 时不再自动切换和创建 client
-	currentConfig, err := readConfig()
+	if err := validateContextName(config.Name); err != nil {
+		return err
+	}
+	if err := validateContextType(config); err != nil {
+		return err
+	}
+	// 通过接口创建的 context 不参与 Docker CLI 同步
+	config.Source = ""
+	groups, err := validateLabels(config.Labels, config.Groups)
 	if err != nil {
 		return err
 	}
+	config.Groups = groups
 
-	contexts, ok := currentConfig["contexts"].(map[string]interface{})
-	if !ok {
-		contexts = make(map[string]interface{})
-		currentConfig["contexts"] = contexts
+	stored, err := newStoredContext(config, nil)
+	if err != nil {
+		return err
 	}
-
-	contexts[config.Name] = map[string]interface{}{
-		"type": config.Type,
-		"host": config.Host,
+	err = s.store.UpdateContexts(func(data *store.ContextState) error {
+		data.Contexts[config.Name] = stored
+		return nil
+	})
+	if err != nil {
+		return err
 	}
-
-	return saveConfig(currentConfig)
+	s.dropRuntime(config.Name)
+	return nil
 }
 
 func (s *DockerService) DeleteContext(name string) error {
-	config, err := readConfig()
+	err := s.store.UpdateContexts(func(data *store.ContextState) error {
+		// 检查是否为当前使用的上下文
+		if data.CurrentContext == name {
+			return fmt.Errorf("cannot delete current context: %s", name)
+		}
+		if _, exists := data.Contexts[name]; !exists {
+			return fmt.Errorf("context %s not found", name)
+		}
+		delete(data.Contexts, name)
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	// 检查是否为当前使用的上下文
-	if currentContext, ok := config["current-context"].(string); ok && currentContext == name {
-		return fmt.Errorf("cannot delete current context: %s", name)
-	}
-
-	contexts, ok := config["contexts"].(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("no contexts found")
-	}
-
-	if _, exists := contexts[name]; !exists {
-		return fmt.Errorf("context %s not found", name)
-	}
-
-	delete(contexts, name)
-	return saveConfig(config)
+	s.dropRuntime(name)
+	return nil
 }
 
 func (s *DockerService) GetContextConfig(name string) (string, error) {
-	config, err := readConfig()
+	data, err := s.store.LoadContexts()
 	if err != nil {
 		return "", err
 	}
 
-	contexts, ok := config["contexts"].(map[string]interface{})
-	if !ok {
-		return "", fmt.Errorf("no contexts found")
-	}
-
-	contextConfig, ok := contexts[name].(map[string]interface{})
+	stored, ok := data.Contexts[name]
 	if !ok {
 		return "", fmt.Errorf("context %s not found", name)
 	}
-
-	host, ok := contextConfig["host"].(string)
-	if !ok {
+	if stored.Host == "" {
 		return "", fmt.Errorf("invalid host configuration for context %s", name)
 	}
 
-	return host, nil
+	return stored.Host, nil
 }
 
 func (s *DockerService) UpdateContextConfig(name string, config ContextConfig) error {
-	currentConfig, err := readConfig()
+	// 手动修改后不再随 Docker CLI 同步
+	config.Source = ""
+	if err := validateContextType(config); err != nil {
+		return err
+	}
+	groups, err := validateLabels(config.Labels, config.Groups)
 	if err != nil {
 		return err
 	}
+	config.Groups = groups
 
-	contexts, ok := currentConfig["contexts"].(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("no contexts found")
-	}
+	hostChanged := false
+	err = s.store.UpdateContexts(func(data *store.ContextState) error {
+		existing, exists := data.Contexts[name]
+		if !exists {
+			return fmt.Errorf("context %s not found", name)
+		}
+		hostChanged = existing.Host != config.Host
+		// 未提交标签和分组时保留原有值，不了解标签的客户端更新连接时不会清空它们
+		if config.Labels == nil {
+			config.Labels = existing.Labels
+		}
+		if config.Groups == nil {
+			config.Groups = existing.Groups
+		}
 
-	if _, exists := contexts[name]; !exists {
-		return fmt.Errorf("context %s not found", name)
+		// 更新配置，证书和私钥留空时保留已保存的内容，TLS/SSH 为空时一并删除
+		stored, err := newStoredContext(config, existing)
+		if err != nil {
+			return err
+		}
+		data.Contexts[name] = stored
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	// 更新配置
-	contexts[name] = map[string]interface{}{
-		"type": config.Type,
-		"host": config.Host,
+	// 丢弃缓存的 client 和 SSH 连接，下次使用时按新配置重建
+	s.dropRuntime(name)
+	if hostChanged {
+		// 原主机的检查结果不适用于新主机，下次检查前不显示健康状态
+		s.health.remove(name)
 	}
+	return nil
+}
 
-	// 如果是当前上下文，更新 Docker 客户端
-	if currentContext, ok := currentConfig["current-context"].(string); ok && currentContext == name {
-		dockerHost := buildDockerHost(config)
-		os.Setenv("DOCKER_HOST", dockerHost)
-
-		cli, err := client.NewClientWithOpts(
-			client.FromEnv,
-			client.WithAPIVersionNegotiation(),
-		)
-		if err != nil {
-			return fmt.Errorf("failed to create docker client: %v", err)
-		}
-		s.clients[name] = cli
+// CurrentContext 返回当前 context 的名称，未设置时返回错误
+func (s *DockerService) CurrentContext() (string, error) {
+	data, err := s.store.LoadContexts()
+	if err != nil {
+		return "", err
 	}
+	if data.CurrentContext == "" {
+		return "", ErrNoCurrentContext
+	}
+	return data.CurrentContext, nil
+}
 
-	return saveConfig(currentConfig)
+// SetCurrentContext 设置当前 context
+func (s *DockerService) SetCurrentContext(name string) error {
+	return s.store.UpdateContexts(func(data *store.ContextState) error {
+		if _, exists := data.Contexts[name]; !exists {
+			return fmt.Errorf("context %s not found", name)
+		}
+		if data.CurrentContext == name {
+			return store.ErrUnchanged
+		}
+		data.CurrentContext = name
+		return nil
+	})
 }
 
-func (s *DockerService) DeleteContainer(contextName string, id string, force bool) error {
-	cli, err := s.getClient(contextName)
+func (s *DockerService) DeleteContainer(ctx context.Context, contextName string, id string, force bool) (err error) {
+	defer logCall(ctx, contextName, "ContainerRemove", time.Now(), &err)
+
+	rt, release, err := s.getRuntime(contextName)
 	if err != nil {
 		return err
 	}
-	options := types.ContainerRemoveOptions{
-		Force:         force, // 如果容器正在运行，是否强制删除
-		RemoveVolumes: false, // 默认不删除关联的匿名卷
-	}
-	return cli.ContainerRemove(context.Background(), id, options)
+	defer release()
+	return rt.DeleteContainer(ctx, id, force)
 }
 
 // CreateExec 创建执行实例
-func (s *DockerService) CreateExec(contextName string, containerID string, config types.ExecConfig) (types.IDResponse, error) {
-	cli, err := s.getClient(contextName)
+func (s *DockerService) CreateExec(ctx context.Context, contextName string, containerID string, config types.ExecConfig) (resp types.IDResponse, err error) {
+	defer logCall(ctx, contextName, "ContainerExecCreate", time.Now(), &err)
+
+	rt, release, err := s.getRuntime(contextName)
 	if err != nil {
 		return types.IDResponse{}, err
 	}
-	return cli.ContainerExecCreate(context.Background(), containerID, config)
+	defer release()
+	return rt.CreateExec(ctx, containerID, config)
 }
 
 // AttachExec 附加到执行实例
-func (s *DockerService) AttachExec(contextName string, execID string, tty bool) (io.ReadWriteCloser, error) {
-	cli, err := s.getClient(contextName)
+func (s *DockerService) AttachExec(ctx context.Context, contextName string, execID string, tty bool) (conn io.ReadWriteCloser, err error) {
+	defer logCall(ctx, contextName, "ContainerExecAttach", time.Now(), &err)
+
+	rt, release, err := s.getRuntime(contextName)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := cli.ContainerExecAttach(context.Background(), execID, types.ExecStartCheck{
-		Tty:    tty,
-		Detach: false,
-	})
+	conn, err = rt.AttachExec(ctx, execID, tty)
 	if err != nil {
-		return nil, fmt.Errorf("failed to attach exec: %v", err)
+		release()
+		return nil, err
 	}
-	return resp.Conn, nil
+	// 连接关闭后才释放运行时，期间健康检查丢弃运行时不会断开终端
+	return &releasingConn{ReadWriteCloser: conn, release: release}, nil
 }
 
 // StartExec 启动执行实例
-func (s *DockerService) StartExec(contextName string, execID string, config types.ExecStartCheck) error {
-	cli, err := s.getClient(contextName)
+func (s *DockerService) StartExec(ctx context.Context, contextName string, execID string, config types.ExecStartCheck) (err error) {
+	defer logCall(ctx, contextName, "ContainerExecStart", time.Now(), &err)
+
+	rt, release, err := s.getRuntime(contextName)
 	if err != nil {
 		return err
 	}
-	err = cli.ContainerExecStart(context.Background(), execID, config)
-	if err != nil {
-		return fmt.Errorf("failed to start exec: %v", err)
-	}
-	return nil
+	defer release()
+	return rt.StartExec(ctx, execID, config)
 }
 
 // ResizeExec 调整终端大小
-func (s *DockerService) ResizeExec(contextName string, execID string, height, width int) error {
-	cli, err := s.getClient(contextName)
+func (s *DockerService) ResizeExec(ctx context.Context, contextName string, execID string, height, width int) (err error) {
+	defer logCall(ctx, contextName, "ContainerExecResize", time.Now(), &err)
+
+	rt, release, err := s.getRuntime(contextName)
 	if err != nil {
 		return err
 	}
-	return cli.ContainerExecResize(context.Background(), execID, types.ResizeOptions{
-		Height: uint(height),
-		Width:  uint(width),
-	})
+	defer release()
+	return rt.ResizeExec(ctx, execID, height, width)
 }
 
 // GetServerInfo 获取服务器信息
-func (s *DockerService) GetServerInfo(contextName string) (types.Info, error) {
-	cli, err := s.getClient(contextName)
-	if err != nil {
-		return types.Info{}, fmt.Errorf("failed to get docker client: %v", err)
-	}
+func (s *DockerService) GetServerInfo(ctx context.Context, contextName string) (info types.Info, err error) {
+	defer logCall(ctx, contextName, "Info", time.Now(), &err)
 
-	info, err := cli.Info(context.Background())
+	rt, release, err := s.getRuntime(contextName)
 	if err != nil {
-		return types.Info{}, fmt.Errorf("failed to get server info: %v", err)
+		return types.Info{}, err
 	}
-
-	return info, nil
+	defer release()
+	return rt.Info(ctx)
 }
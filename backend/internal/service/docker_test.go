@@ -0,0 +1,50 @@
+package service
+
+import "testing"
+
+// closeRecorder 只记录是否被关闭的运行时
+type closeRecorder struct {
+	Runtime
+	closed bool
+}
+
+func (r *closeRecorder) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestRemoveRuntimeGeneration(t *testing.T) {
+	s, _ := NewDockerService(nil)
+	inUse := &closeRecorder{}
+	s.runtimes["a"] = &cachedRuntime{Runtime: inUse, refs: 1}
+
+	// 没有缓存的 context 被健康检查移除时，不影响正在创建的运行时
+	s.evictRuntime("b")
+	if got := s.generations["b"]; got != 0 {
+		t.Errorf("generation of b after evicting nothing = %d, want 0", got)
+	}
+
+	s.evictRuntime("a")
+	if got := s.generations["a"]; got != 1 {
+		t.Errorf("generation of a after evict = %d, want 1", got)
+	}
+	if got := s.generations["b"]; got != 0 {
+		t.Errorf("evicting a changed generation of b to %d", got)
+	}
+	if inUse.closed {
+		t.Error("evicted runtime was closed while in use")
+	}
+
+	// 配置变化时即使没有缓存也要递增
+	s.dropRuntime("b")
+	if got := s.generations["b"]; got != 1 {
+		t.Errorf("generation of b after config change = %d, want 1", got)
+	}
+
+	idle := &closeRecorder{}
+	s.runtimes["c"] = &cachedRuntime{Runtime: idle}
+	s.evictRuntime("c")
+	if !idle.closed {
+		t.Error("idle runtime was not closed on evict")
+	}
+}
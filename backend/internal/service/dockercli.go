@@ -0,0 +1,396 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/smartcat999/container-ui/internal/store"
+)
+
+const (
+	// contextSourceDockerCLI 标记从 Docker CLI context 存储导入的 context，同步时会随之更新和删除
+	contextSourceDockerCLI = "docker-cli"
+
+	dockerEndpointName = "docker"
+)
+
+// dockerCLIMeta ~/.docker/contexts/meta/<id>/meta.json 的内容
+type dockerCLIMeta struct {
+	Name      string                       `json:"Name"`
+	Metadata  map[string]interface{}       `json:"Metadata,omitempty"`
+	Endpoints map[string]dockerCLIEndpoint `json:"Endpoints"`
+}
+
+// dockerCLIEndpoint Docker CLI context 中的 docker endpoint
+type dockerCLIEndpoint struct {
+	Host          string `json:"Host,omitempty"`
+	SkipTLSVerify bool   `json:"SkipTLSVerify"`
+}
+
+// DockerCLISyncOptions 导入或导出 Docker CLI context 的选项
+type DockerCLISyncOptions struct {
+	// Names 需要处理的 context，为空表示全部
+	Names []string `json:"names"`
+	// Overwrite 是否覆盖同名的 context，导入时已从 Docker CLI 导入过的 context 总会被更新
+	Overwrite bool `json:"overwrite"`
+	// Prune 导入时删除已从 Docker CLI 存储中移除的 context，不会删除当前 context
+	Prune bool `json:"prune"`
+}
+
+// DockerCLISyncResult 导入或导出的结果
+type DockerCLISyncResult struct {
+	Created []string          `json:"created"`
+	Updated []string          `json:"updated"`
+	Skipped []string          `json:"skipped"`
+	Removed []string          `json:"removed"`
+	Errors  map[string]string `json:"errors,omitempty"`
+}
+
+func newDockerCLISyncResult() *DockerCLISyncResult {
+	return &DockerCLISyncResult{
+		Created: []string{},
+		Updated: []string{},
+		Skipped: []string{},
+		Removed: []string{},
+	}
+}
+
+func (r *DockerCLISyncResult) fail(name string, err error) {
+	if r.Errors == nil {
+		r.Errors = make(map[string]string)
+	}
+	r.Errors[name] = err.Error()
+}
+
+// getDockerCLIDir 获取 Docker CLI 配置目录，与 docker 一样优先使用 DOCKER_CONFIG
+func getDockerCLIDir() (string, error) {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate docker config directory: %v", err)
+	}
+	return filepath.Join(home, ".docker"), nil
+}
+
+// dockerCLIContextID Docker CLI 以 context 名称的 sha256 作为存储目录名
+func dockerCLIContextID(name string) string {
+	return digest.FromString(name).Encoded()
+}
+
+// readDockerCLIContexts 读取 Docker CLI context 存储中的所有 context，TLS 证书一并读出。
+// 无法解析的 meta.json 不影响其他 context，以存储目录名（context 名称的 sha256）为键在 invalid 中返回
+func readDockerCLIContexts(dockerDir string) (contexts map[string]ContextConfig, invalid map[string]error, err error) {
+	metaDir := filepath.Join(dockerDir, "contexts", "meta")
+	entries, err := os.ReadDir(metaDir)
+	if os.IsNotExist(err) {
+		return map[string]ContextConfig{}, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contexts = make(map[string]ContextConfig)
+	invalid = make(map[string]error)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(metaDir, entry.Name(), "meta.json"))
+		if err != nil {
+			continue
+		}
+		var meta dockerCLIMeta
+		if err := json.Unmarshal(data, &meta); err != nil {
+			invalid[entry.Name()] = fmt.Errorf("failed to parse docker context %s: %v", entry.Name(), err)
+			continue
+		}
+		endpoint, ok := meta.Endpoints[dockerEndpointName]
+		if !ok || meta.Name == "" {
+			continue
+		}
+
+		config := ContextConfig{
+			Name: meta.Name,
+			Host: endpoint.Host,
+		}
+		tlsDir := filepath.Join(dockerDir, "contexts", "tls", entry.Name(), dockerEndpointName)
+		ca, _ := os.ReadFile(filepath.Join(tlsDir, caFileName))
+		cert, _ := os.ReadFile(filepath.Join(tlsDir, certFileName))
+		key, _ := os.ReadFile(filepath.Join(tlsDir, keyFileName))
+		// 与 docker 一致，存在证书或显式跳过校验时才使用 TLS
+		if len(ca) > 0 || len(cert) > 0 || endpoint.SkipTLSVerify {
+			config.TLS = &TLSConfig{
+				Verify: !endpoint.SkipTLSVerify,
+				CA:     string(ca),
+				Cert:   string(cert),
+				Key:    string(key),
+			}
+		}
+		contexts[meta.Name] = config
+	}
+	return contexts, invalid, nil
+}
+
+// ImportDockerCLIContexts 从 Docker CLI 的 context 存储（~/.docker/contexts）导入 context
+func (s *DockerService) ImportDockerCLIContexts(opts DockerCLISyncOptions) (*DockerCLISyncResult, error) {
+	dockerDir, err := getDockerCLIDir()
+	if err != nil {
+		return nil, err
+	}
+	cliContexts, invalid, err := readDockerCLIContexts(dockerDir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newDockerCLISyncResult()
+	// 无法解析的 context 不知道名称，指定了名称时只报告其中的，否则以存储目录名报告
+	if len(opts.Names) == 0 {
+		for id, err := range invalid {
+			result.fail(id, err)
+		}
+	}
+	var changed []string
+	err = s.store.UpdateContexts(func(data *store.ContextState) error {
+		for _, name := range selectedNames(cliContexts, opts.Names) {
+			cliContext, ok := cliContexts[name]
+			if !ok {
+				if err, bad := invalid[dockerCLIContextID(name)]; bad {
+					result.fail(name, err)
+				} else {
+					result.fail(name, fmt.Errorf("docker context %s not found", name))
+				}
+				continue
+			}
+			existing, exists := data.Contexts[name]
+			if exists && existing.Source != contextSourceDockerCLI && !opts.Overwrite {
+				result.Skipped = append(result.Skipped, name)
+				continue
+			}
+
+			if err := validateContextName(name); err != nil {
+				result.fail(name, err)
+				continue
+			}
+			contextType, err := store.TypeForHost(cliContext.Host)
+			if err != nil {
+				result.fail(name, err)
+				continue
+			}
+			cliContext.Type = contextType
+			cliContext.Source = contextSourceDockerCLI
+			// Docker CLI 的 ssh context 不保存私钥，使用 ssh-agent 和默认的 known_hosts
+			if contextType == "ssh" {
+				cliContext.SSH = &SSHConfig{}
+			}
+			// 导入的证书完全替换已保存的证书，而不是只覆盖非空部分
+			stored, err := newStoredContext(cliContext, nil)
+			if err != nil {
+				result.fail(name, err)
+				continue
+			}
+			// 标签和分组只在本服务中维护，导入时保留
+			if exists {
+				stored.Labels = existing.Labels
+				stored.Groups = existing.Groups
+			}
+
+			if exists && reflect.DeepEqual(existing, stored) {
+				continue
+			}
+			data.Contexts[name] = stored
+			changed = append(changed, name)
+			if exists {
+				result.Updated = append(result.Updated, name)
+			} else {
+				result.Created = append(result.Created, name)
+			}
+		}
+
+		if opts.Prune {
+			for name, stored := range data.Contexts {
+				if stored.Source != contextSourceDockerCLI || name == data.CurrentContext {
+					continue
+				}
+				// 无法解析的 context 可能仍然存在，不删除
+				if _, ok := cliContexts[name]; ok {
+					continue
+				}
+				if _, bad := invalid[dockerCLIContextID(name)]; bad {
+					continue
+				}
+				delete(data.Contexts, name)
+				changed = append(changed, name)
+				result.Removed = append(result.Removed, name)
+			}
+			sort.Strings(result.Removed)
+		}
+		if len(changed) == 0 {
+			return store.ErrUnchanged
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range changed {
+		s.dropRuntime(name)
+	}
+	return result, nil
+}
+
+// ExportDockerCLIContexts 将 context 写入 Docker CLI 的 context 存储，之后可通过 docker context use 使用。
+// Docker CLI 不支持 SSH 私钥和跳板机，ssh context 仅导出 host；containerd context 不能导出
+func (s *DockerService) ExportDockerCLIContexts(opts DockerCLISyncOptions) (*DockerCLISyncResult, error) {
+	dockerDir, err := getDockerCLIDir()
+	if err != nil {
+		return nil, err
+	}
+	cliContexts, invalid, err := readDockerCLIContexts(dockerDir)
+	if err != nil {
+		return nil, err
+	}
+	// 导出时需要证书，直接读取保存的 context
+	data, err := s.store.LoadContexts()
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]ContextConfig, len(data.Contexts))
+	for name, stored := range data.Contexts {
+		byName[name] = toRuntimeConfig(name, stored)
+	}
+
+	result := newDockerCLISyncResult()
+	for _, name := range selectedNames(byName, opts.Names) {
+		local, ok := byName[name]
+		if !ok {
+			result.fail(name, fmt.Errorf("context %s not found", name))
+			continue
+		}
+		// Docker CLI 无法连接 containerd，Podman 提供兼容 Docker 的 API，可以导出
+		if local.Type == ContextTypeContainerd {
+			result.fail(name, fmt.Errorf("containerd context %s cannot be used by the docker CLI", name))
+			continue
+		}
+		// 无法解析的同名 context 视为已存在，指定 overwrite 时覆盖
+		_, exists := cliContexts[name]
+		if _, bad := invalid[dockerCLIContextID(name)]; bad {
+			exists = true
+		}
+		if name == "default" || (exists && !opts.Overwrite) {
+			result.Skipped = append(result.Skipped, name)
+			continue
+		}
+		if err := writeDockerCLIContext(dockerDir, local); err != nil {
+			result.fail(name, err)
+			continue
+		}
+		if exists {
+			result.Updated = append(result.Updated, name)
+		} else {
+			result.Created = append(result.Created, name)
+		}
+	}
+	return result, nil
+}
+
+// writeDockerCLIContext 按 Docker CLI 的格式写入 meta.json 和 TLS 证书
+func writeDockerCLIContext(dockerDir string, config ContextConfig) error {
+	id := dockerCLIContextID(config.Name)
+	endpoint := dockerCLIEndpoint{Host: config.Host}
+	if config.TLS != nil {
+		endpoint.SkipTLSVerify = !config.TLS.Verify
+	}
+	meta := dockerCLIMeta{
+		Name:      config.Name,
+		Metadata:  map[string]interface{}{},
+		Endpoints: map[string]dockerCLIEndpoint{dockerEndpointName: endpoint},
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	metaDir := filepath.Join(dockerDir, "contexts", "meta", id)
+	if err := os.MkdirAll(metaDir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(metaDir, "meta.json"), data, 0644); err != nil {
+		return err
+	}
+
+	tlsDir := filepath.Join(dockerDir, "contexts", "tls", id)
+	if err := os.RemoveAll(tlsDir); err != nil {
+		return err
+	}
+	if config.TLS == nil {
+		return nil
+	}
+	endpointDir := filepath.Join(tlsDir, dockerEndpointName)
+	if err := os.MkdirAll(endpointDir, 0700); err != nil {
+		return err
+	}
+	files := map[string]string{
+		caFileName:   config.TLS.CA,
+		certFileName: config.TLS.Cert,
+		keyFileName:  config.TLS.Key,
+	}
+	for file, content := range files {
+		if content == "" {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(endpointDir, file), []byte(content), 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SyncDockerCLIContexts 按间隔从 Docker CLI 的 context 存储同步 context，直到 ctx 取消。
+// 仅更新和删除从 Docker CLI 导入的 context，不会覆盖在本服务中创建的同名 context
+func (s *DockerService) SyncDockerCLIContexts(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		result, err := s.ImportDockerCLIContexts(DockerCLISyncOptions{Prune: true})
+		if err != nil {
+			slog.Warn("failed to sync docker cli contexts", "error", err)
+		} else if len(result.Created)+len(result.Updated)+len(result.Removed) > 0 || len(result.Errors) > 0 {
+			slog.Info("synced docker cli contexts",
+				"created", result.Created,
+				"updated", result.Updated,
+				"removed", result.Removed,
+				"errors", result.Errors,
+			)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// selectedNames 返回需要处理的 context 名称，names 为空时返回全部
+func selectedNames[T any](all map[string]T, names []string) []string {
+	if len(names) > 0 {
+		return names
+	}
+	result := make([]string, 0, len(all))
+	for name := range all {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadDockerCLIContextsSkipsMalformed(t *testing.T) {
+	dockerDir := t.TempDir()
+	if err := writeDockerCLIContext(dockerDir, ContextConfig{Name: "remote", Host: "tcp://10.0.0.1:2375"}); err != nil {
+		t.Fatal(err)
+	}
+	brokenID := dockerCLIContextID("broken")
+	brokenDir := filepath.Join(dockerDir, "contexts", "meta", brokenID)
+	if err := os.MkdirAll(brokenDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(brokenDir, "meta.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	contexts, invalid, err := readDockerCLIContexts(dockerDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := contexts["remote"].Host; len(contexts) != 1 || got != "tcp://10.0.0.1:2375" {
+		t.Errorf("contexts = %+v, want only remote", contexts)
+	}
+	if len(invalid) != 1 || invalid[brokenID] == nil {
+		t.Errorf("invalid = %v, want %s", invalid, brokenID)
+	}
+}
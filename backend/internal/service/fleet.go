@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultFleetTimeout 跨 context 查询时单个 context 的默认超时
+	DefaultFleetTimeout = 10 * time.Second
+	// MaxFleetTimeout 单个 context 允许的最长超时
+	MaxFleetTimeout = time.Minute
+	// fleetConcurrency 同时查询的 context 数量上限，避免同时建立过多 SSH 连接
+	fleetConcurrency = 16
+)
+
+// 跨 context 查询中单个 context 的状态
+const (
+	FleetStatusOK      = "ok"
+	FleetStatusError   = "error"
+	FleetStatusTimeout = "timeout"
+)
+
+// FleetQuery 跨 context 查询的条件
+type FleetQuery struct {
+	// Selector 选择参与查询的 context
+	Selector ContextSelector
+	// Timeout 单个 context 的超时，为 0 时使用 DefaultFleetTimeout
+	Timeout time.Duration
+	// Search 按名称、镜像或 ID 搜索，不区分大小写
+	Search string
+	// Labels 资源（容器、镜像、数据卷）需满足的标签条件
+	Labels LabelSelector
+}
+
+// matches 判断资源是否满足搜索条件，fields 为可搜索的名称、镜像等
+func (q FleetQuery) matches(labels map[string]string, fields ...string) bool {
+	if !q.Labels.Matches(labels) {
+		return false
+	}
+	if q.Search == "" {
+		return true
+	}
+	search := strings.ToLower(q.Search)
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), search) {
+			return true
+		}
+	}
+	return false
+}
+
+// FleetContextStatus 一个 context 的查询结果
+type FleetContextStatus struct {
+	Context   string `json:"context"`
+	Status    string `json:"status"`
+	Count     int    `json:"count"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+// FleetResult 跨 context 查询的结果，部分 context 失败或超时时其余 context 的结果照常返回
+type FleetResult[T any] struct {
+	Items []T `json:"items"`
+	// Contexts 参与查询的每个 context 的状态
+	Contexts []FleetContextStatus `json:"contexts"`
+	// Partial 为 true 表示有 context 查询失败，结果不完整
+	Partial bool `json:"partial"`
+}
+
+// FleetContainer 带所属 context 的容器
+type FleetContainer struct {
+	Context string `json:"context"`
+	ContainerInfo
+}
+
+// FleetImage 带所属 context 的镜像
+type FleetImage struct {
+	Context string `json:"context"`
+	ImageInfo
+}
+
+// FleetVolume 带所属 context 的数据卷
+type FleetVolume struct {
+	Context string `json:"context"`
+	VolumeInfo
+}
+
+// fanOut 对满足条件的 context 并发执行 list，每个 context 单独计时，结果按 context 名称顺序合并
+func fanOut[T any](ctx context.Context, s *DockerService, query FleetQuery, list func(ctx context.Context, contextName string) ([]T, error)) (*FleetResult[T], error) {
+	names, err := s.selectContexts(query.Selector)
+	if err != nil {
+		return nil, err
+	}
+	timeout := query.Timeout
+	if timeout <= 0 {
+		timeout = DefaultFleetTimeout
+	}
+
+	items := make([][]T, len(names))
+	statuses := make([]FleetContextStatus, len(names))
+	sem := make(chan struct{}, fleetConcurrency)
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			queryCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			start := time.Now()
+			result, err := list(queryCtx, name)
+			status := FleetContextStatus{
+				Context:   name,
+				Status:    FleetStatusOK,
+				Count:     len(result),
+				LatencyMs: time.Since(start).Milliseconds(),
+			}
+			switch {
+			case err != nil && errors.Is(queryCtx.Err(), context.DeadlineExceeded):
+				status.Status = FleetStatusTimeout
+				status.Error = fmt.Sprintf("timed out after %s", timeout)
+			case err != nil:
+				status.Status = FleetStatusError
+				status.Error = err.Error()
+			default:
+				items[i] = result
+			}
+			statuses[i] = status
+		}(i, name)
+	}
+	wg.Wait()
+
+	merged := &FleetResult[T]{
+		Items:    []T{},
+		Contexts: statuses,
+	}
+	for i := range names {
+		merged.Items = append(merged.Items, items[i]...)
+		if statuses[i].Status != FleetStatusOK {
+			merged.Partial = true
+		}
+	}
+	return merged, nil
+}
+
+// ListFleetContainers 列出所有满足条件的 context 中的容器，可按名称、镜像、ID 和容器标签搜索
+func (s *DockerService) ListFleetContainers(ctx context.Context, query FleetQuery) (*FleetResult[FleetContainer], error) {
+	return fanOut(ctx, s, query, func(ctx context.Context, contextName string) ([]FleetContainer, error) {
+		page, err := s.ListContainers(ctx, contextName, ListOptions{Filter: ListFilter{Labels: query.Labels}})
+		if err != nil {
+			return nil, err
+		}
+		containers := page.Items
+		items := make([]FleetContainer, 0, len(containers))
+		for _, container := range containers {
+			if query.matches(container.Labels, container.Name, container.Image, container.ID) {
+				items = append(items, FleetContainer{Context: contextName, ContainerInfo: container})
+			}
+		}
+		// 同一 context 内按名称排序
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].Name < items[j].Name
+		})
+		return items, nil
+	})
+}
+
+// ListFleetImages 列出所有满足条件的 context 中的镜像，可按仓库名、标签、ID 和镜像标签搜索
+func (s *DockerService) ListFleetImages(ctx context.Context, query FleetQuery) (*FleetResult[FleetImage], error) {
+	return fanOut(ctx, s, query, func(ctx context.Context, contextName string) ([]FleetImage, error) {
+		page, err := s.ListImages(ctx, contextName, ListOptions{Filter: ListFilter{Labels: query.Labels}})
+		if err != nil {
+			return nil, err
+		}
+		images := page.Items
+		items := make([]FleetImage, 0, len(images))
+		for _, image := range images {
+			if query.matches(image.Labels, image.Repository+":"+image.Tag, image.ID) {
+				items = append(items, FleetImage{Context: contextName, ImageInfo: image})
+			}
+		}
+		sort.Slice(items, func(i, j int) bool {
+			if items[i].Repository != items[j].Repository {
+				return items[i].Repository < items[j].Repository
+			}
+			return items[i].Tag < items[j].Tag
+		})
+		return items, nil
+	})
+}
+
+// ListFleetVolumes 列出所有满足条件的 context 中的数据卷，可按名称、驱动和数据卷标签搜索
+func (s *DockerService) ListFleetVolumes(ctx context.Context, query FleetQuery) (*FleetResult[FleetVolume], error) {
+	return fanOut(ctx, s, query, func(ctx context.Context, contextName string) ([]FleetVolume, error) {
+		page, err := s.ListVolumes(ctx, contextName, ListOptions{Filter: ListFilter{Labels: query.Labels}})
+		if err != nil {
+			return nil, err
+		}
+		volumes := page.Items
+		items := make([]FleetVolume, 0, len(volumes))
+		for _, volume := range volumes {
+			if query.matches(volume.Labels, volume.Name, volume.Driver) {
+				items = append(items, FleetVolume{Context: contextName, VolumeInfo: volume})
+			}
+		}
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].Name < items[j].Name
+		})
+		return items, nil
+	})
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+// 健康检查状态
+const (
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+)
+
+// ContextHealth context 最近一次健康检查的结果
+type ContextHealth struct {
+	Status string `json:"status"`
+	// LatencyMs 最近一次 ping 的耗时
+	LatencyMs  int64  `json:"latencyMs"`
+	APIVersion string `json:"apiVersion,omitempty"`
+	OSType     string `json:"osType,omitempty"`
+	// ConsecutiveFailures 连续失败次数，成功后清零
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	LastError           string     `json:"lastError,omitempty"`
+	LastChecked         time.Time  `json:"lastChecked"`
+	LastHealthy         *time.Time `json:"lastHealthy,omitempty"`
+}
+
+// healthRegistry 保存各 context 的健康状态
+type healthRegistry struct {
+	mu      sync.RWMutex
+	results map[string]ContextHealth
+}
+
+func (r *healthRegistry) get(name string) *ContextHealth {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if health, ok := r.results[name]; ok {
+		return &health
+	}
+	return nil
+}
+
+func (r *healthRegistry) set(name string, health ContextHealth) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.results == nil {
+		r.results = make(map[string]ContextHealth)
+	}
+	r.results[name] = health
+}
+
+func (r *healthRegistry) remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.results, name)
+}
+
+// retain 删除已不存在的 context 的健康状态
+func (r *healthRegistry) retain(names map[string]bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name := range r.results {
+		if !names[name] {
+			delete(r.results, name)
+		}
+	}
+}
+
+// MonitorHealth 按间隔并发检查所有 context，直到 ctx 取消。
+// 检查失败时从缓存中移除运行时和 SSH 连接，下次使用或检查时重新建立；正在使用它们的调用不受影响
+func (s *DockerService) MonitorHealth(ctx context.Context, interval, timeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.CheckHealth(ctx, timeout)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckHealth 并发检查所有 context，每个 context 的检查不超过 timeout
+func (s *DockerService) CheckHealth(ctx context.Context, timeout time.Duration) {
+	data, err := s.store.LoadContexts()
+	if err != nil {
+		slog.Warn("failed to load contexts for health check", "error", err)
+		return
+	}
+
+	names := make(map[string]bool, len(data.Contexts))
+	var wg sync.WaitGroup
+	for name := range data.Contexts {
+		names[name] = true
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			s.checkContext(checkCtx, name)
+		}(name)
+	}
+	wg.Wait()
+	s.health.retain(names)
+}
+
+// checkContext ping 一个 context 并记录结果
+func (s *DockerService) checkContext(ctx context.Context, name string) {
+	previous := s.health.get(name)
+	health := ContextHealth{
+		Status:      HealthHealthy,
+		LastChecked: time.Now(),
+	}
+	if previous != nil {
+		health.LastHealthy = previous.LastHealthy
+		health.ConsecutiveFailures = previous.ConsecutiveFailures
+	}
+
+	start := time.Now()
+	ping, err := s.ping(ctx, name)
+	health.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		// 服务退出时取消的检查不计入结果
+		return
+	}
+
+	if err != nil {
+		health.Status = HealthUnhealthy
+		health.LastError = err.Error()
+		health.ConsecutiveFailures++
+		// 一次检查失败可能只是网络抖动，不关闭正在使用的连接
+		s.evictRuntime(name)
+	} else {
+		health.APIVersion = ping.APIVersion
+		health.OSType = ping.OSType
+		health.ConsecutiveFailures = 0
+		health.LastHealthy = &health.LastChecked
+	}
+	s.health.set(name, health)
+
+	if previous == nil || previous.Status != health.Status {
+		l := slog.With("context", name, "status", health.Status, "latency_ms", health.LatencyMs)
+		if err != nil {
+			l.Warn("context health changed", "error", err)
+		} else {
+			l.Info("context health changed", "api_version", health.APIVersion)
+		}
+	}
+}
+
+// ping 检查运行时是否可用（Docker 和 Podman 调用 /_ping），结果由健康检查记录，不再逐次记录日志
+func (s *DockerService) ping(ctx context.Context, contextName string) (types.Ping, error) {
+	rt, release, err := s.getRuntime(contextName)
+	if err != nil {
+		return types.Ping{}, err
+	}
+	defer release()
+	return rt.Ping(ctx)
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/smartcat999/container-ui/internal/store"
+)
+
+// 标签键的格式与 Kubernetes 标签类似，可带 / 分隔的前缀，如 team 或 example.com/team
+var labelKeyPattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9_./-]*[a-zA-Z0-9])?$`)
+
+// 标签值可以为空
+var labelValuePattern = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9_.-]*[a-zA-Z0-9])?)?$`)
+
+// ContextGroup 一个分组及其中的 context
+type ContextGroup struct {
+	Name     string   `json:"name"`
+	Contexts []string `json:"contexts"`
+}
+
+// labelRequirement 一条标签条件
+type labelRequirement struct {
+	key   string
+	value string
+	// op 为 exists、= 或 !=
+	op string
+}
+
+// LabelSelector 标签条件，格式为 key（存在该标签）、key=value 或 key!=value，多个条件需同时满足
+type LabelSelector []labelRequirement
+
+// ParseLabelSelector 解析标签条件，每一项可以是逗号分隔的多个条件，如 env=prod,region
+func ParseLabelSelector(exprs []string) (LabelSelector, error) {
+	var selector LabelSelector
+	for _, expr := range exprs {
+		for _, part := range splitList(expr) {
+			req, err := parseLabelRequirement(part)
+			if err != nil {
+				return nil, err
+			}
+			selector = append(selector, req)
+		}
+	}
+	return selector, nil
+}
+
+func parseLabelRequirement(expr string) (labelRequirement, error) {
+	req := labelRequirement{key: expr, op: "exists"}
+	if key, value, ok := strings.Cut(expr, "!="); ok {
+		req = labelRequirement{key: key, value: value, op: "!="}
+	} else if key, value, ok := strings.Cut(expr, "="); ok {
+		req = labelRequirement{key: key, value: value, op: "="}
+	}
+	req.key = strings.TrimSpace(req.key)
+	req.value = strings.TrimSpace(req.value)
+	if !labelKeyPattern.MatchString(req.key) {
+		return labelRequirement{}, fmt.Errorf("invalid label selector %q", expr)
+	}
+	return req, nil
+}
+
+// Matches 判断标签是否满足所有条件
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, req := range s {
+		value, ok := labels[req.key]
+		switch req.op {
+		case "exists":
+			if !ok {
+				return false
+			}
+		case "=":
+			if !ok || value != req.value {
+				return false
+			}
+		case "!=":
+			if ok && value == req.value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ContextSelector 按名称、标签和分组筛选 context，为空时匹配所有 context
+type ContextSelector struct {
+	names  []string
+	labels LabelSelector
+	groups []string
+}
+
+// ParseContextSelector 解析 context 的筛选条件。
+// 指定名称时只匹配这些 context；标签条件需同时满足；指定多个分组时属于其中任一分组即可
+func ParseContextSelector(names, labels, groups []string) (ContextSelector, error) {
+	labelSelector, err := ParseLabelSelector(labels)
+	if err != nil {
+		return ContextSelector{}, err
+	}
+	selector := ContextSelector{labels: labelSelector}
+	for _, name := range names {
+		selector.names = append(selector.names, splitList(name)...)
+	}
+	for _, group := range groups {
+		selector.groups = append(selector.groups, splitList(group)...)
+	}
+	return selector, nil
+}
+
+// splitList 拆分逗号分隔的参数，同时支持 a,b 和重复的参数
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Matches 判断 context 是否满足条件
+func (s ContextSelector) Matches(name string, stored *store.Context) bool {
+	if len(s.names) > 0 && !contains(s.names, name) {
+		return false
+	}
+	if !s.labels.Matches(stored.Labels) {
+		return false
+	}
+	if len(s.groups) == 0 {
+		return true
+	}
+	for _, group := range s.groups {
+		if contains(stored.Groups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(items []string, item string) bool {
+	for _, v := range items {
+		if v == item {
+			return true
+		}
+	}
+	return false
+}
+
+// validateLabels 校验 context 的标签和分组，返回去重排序后的分组
+func validateLabels(labels map[string]string, groups []string) ([]string, error) {
+	for key, value := range labels {
+		if !labelKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid label key %q: must match %s", key, labelKeyPattern.String())
+		}
+		if !labelValuePattern.MatchString(value) {
+			return nil, fmt.Errorf("invalid value %q for label %s: must match %s", value, key, labelValuePattern.String())
+		}
+	}
+	if groups == nil {
+		return nil, nil
+	}
+	normalized := make([]string, 0, len(groups))
+	seen := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		// 分组名与 context 名规则相同
+		if !contextNamePattern.MatchString(group) {
+			return nil, fmt.Errorf("invalid group name %q: must match %s", group, contextNamePattern.String())
+		}
+		if !seen[group] {
+			seen[group] = true
+			normalized = append(normalized, group)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// SetContextLabels 替换 context 的标签和分组。
+// 与 UpdateContextConfig 不同，只修改标签和分组，从 Docker CLI 导入的 context 仍会继续同步
+func (s *DockerService) SetContextLabels(name string, labels map[string]string, groups []string) error {
+	groups, err := validateLabels(labels, groups)
+	if err != nil {
+		return err
+	}
+	return s.store.UpdateContexts(func(data *store.ContextState) error {
+		stored, exists := data.Contexts[name]
+		if !exists {
+			return fmt.Errorf("context %s not found", name)
+		}
+		stored.Labels = labels
+		stored.Groups = groups
+		return nil
+	})
+}
+
+// ListGroups 返回所有分组及其中的 context，按名称排序
+func (s *DockerService) ListGroups() ([]ContextGroup, error) {
+	data, err := s.store.LoadContexts()
+	if err != nil {
+		return nil, err
+	}
+
+	members := make(map[string][]string)
+	for name, stored := range data.Contexts {
+		for _, group := range stored.Groups {
+			members[group] = append(members[group], name)
+		}
+	}
+	groups := make([]ContextGroup, 0, len(members))
+	for group, names := range members {
+		sort.Strings(names)
+		groups = append(groups, ContextGroup{Name: group, Contexts: names})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+	return groups, nil
+}
+
+// selectContexts 返回满足条件的 context 名称，按名称排序
+func (s *DockerService) selectContexts(selector ContextSelector) ([]string, error) {
+	data, err := s.store.LoadContexts()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for name, stored := range data.Contexts {
+		if selector.Matches(name, stored) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	tests := []struct {
+		name    string
+		exprs   []string
+		want    LabelSelector
+		wantErr bool
+	}{
+		{"empty", nil, nil, false},
+		{"exists", []string{"env"}, LabelSelector{{key: "env", op: "exists"}}, false},
+		{"equal", []string{"env=prod"}, LabelSelector{{key: "env", value: "prod", op: "="}}, false},
+		{"not equal", []string{"env!=prod"}, LabelSelector{{key: "env", value: "prod", op: "!="}}, false},
+		{"empty value", []string{"env="}, LabelSelector{{key: "env", op: "="}}, false},
+		{"prefixed key", []string{"example.com/team=infra"}, LabelSelector{{key: "example.com/team", value: "infra", op: "="}}, false},
+		{
+			"comma separated and repeated",
+			[]string{"env=prod, region", "tier!=db"},
+			LabelSelector{
+				{key: "env", value: "prod", op: "="},
+				{key: "region", op: "exists"},
+				{key: "tier", value: "db", op: "!="},
+			},
+			false,
+		},
+		{"missing key", []string{"=prod"}, nil, true},
+		{"invalid key", []string{"-env=prod"}, nil, true},
+		{"invalid key in list", []string{"env=prod,a b"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLabelSelector(tt.exprs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLabelSelector(%q) error = %v, wantErr %v", tt.exprs, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLabelSelector(%q) = %+v, want %+v", tt.exprs, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabelSelectorMatches(t *testing.T) {
+	labels := map[string]string{"env": "prod", "region": ""}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"env", true},
+		{"region", true},
+		{"tier", false},
+		{"env=prod", true},
+		{"env=dev", false},
+		{"region=", true},
+		{"tier=", false},
+		{"env!=dev", true},
+		{"env!=prod", false},
+		// 不存在的标签满足 !=
+		{"tier!=db", true},
+		{"env=prod,tier", false},
+		{"env=prod,region", true},
+	}
+	for _, tt := range tests {
+		selector, err := ParseLabelSelector([]string{tt.expr})
+		if err != nil {
+			t.Fatalf("ParseLabelSelector(%q): %v", tt.expr, err)
+		}
+		if got := selector.Matches(labels); got != tt.want {
+			t.Errorf("%q.Matches(%v) = %v, want %v", tt.expr, labels, got, tt.want)
+		}
+	}
+	if !(LabelSelector(nil)).Matches(nil) {
+		t.Error("empty selector should match everything")
+	}
+}
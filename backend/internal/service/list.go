@@ -0,0 +1,417 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// 可以筛选和分页的资源
+const (
+	ResourceContainer = "container"
+	ResourceImage     = "image"
+	ResourceNetwork   = "network"
+	ResourceVolume    = "volume"
+)
+
+// 列表的排序字段
+const (
+	SortByName    = "name"
+	SortByCreated = "created"
+	SortByState   = "state"
+	SortByImage   = "image"
+	SortBySize    = "size"
+	SortByDriver  = "driver"
+)
+
+// MaxListLimit 每页最多返回的数量
+const MaxListLimit = 1000
+
+// DefaultListLimit 列表接口指定了 cursor 但未指定 limit 时每页返回的数量
+const DefaultListLimit = 100
+
+// containerStates Docker 的容器状态，用于 status 筛选
+var containerStates = []string{"created", "restarting", "running", "removing", "paused", "exited", "dead"}
+
+// listResource 一类资源支持的排序字段和筛选条件
+type listResource struct {
+	sortFields []string
+	status     bool
+	ancestor   bool
+	dangling   bool
+	// nameFilter 名称筛选对应的 Docker 过滤器，镜像为 reference
+	nameFilter string
+}
+
+var listResources = map[string]listResource{
+	ResourceContainer: {
+		sortFields: []string{SortByName, SortByCreated, SortByState, SortByImage},
+		status:     true,
+		ancestor:   true,
+		nameFilter: "name",
+	},
+	ResourceImage: {
+		sortFields: []string{SortByName, SortByCreated, SortBySize},
+		dangling:   true,
+		nameFilter: "reference",
+	},
+	ResourceNetwork: {
+		sortFields: []string{SortByName, SortByCreated, SortByDriver},
+		dangling:   true,
+		nameFilter: "name",
+	},
+	ResourceVolume: {
+		sortFields: []string{SortByName, SortByCreated, SortByDriver},
+		dangling:   true,
+		nameFilter: "name",
+	},
+}
+
+// ListFilter 列表的筛选条件，同一条件的多个值满足其一即可，不同条件需同时满足。
+// Docker 和 Podman 由 daemon 筛选，containerd 在本地筛选
+type ListFilter struct {
+	// Status 容器状态，如 running、exited
+	Status []string
+	// Labels 标签条件，key!=value 不被 Docker 支持，在本地筛选
+	Labels LabelSelector
+	// Name 容器、网络和数据卷的名称包含该字符串；镜像按引用匹配，支持通配符，如 nginx 或 nginx:1.*
+	Name []string
+	// Ancestor 基于该镜像或其子镜像创建的容器
+	Ancestor []string
+	// Dangling 为 true 时只列出悬空镜像、未使用的网络和数据卷，为 false 时排除它们
+	Dangling *bool
+}
+
+func (f ListFilter) validate(resource string, spec listResource) error {
+	if len(f.Status) > 0 && !spec.status {
+		return fmt.Errorf("status filter is not supported for %ss", resource)
+	}
+	for _, status := range f.Status {
+		if !contains(containerStates, status) {
+			return fmt.Errorf("invalid status %q: must be one of %s", status, strings.Join(containerStates, ", "))
+		}
+	}
+	if len(f.Ancestor) > 0 && !spec.ancestor {
+		return fmt.Errorf("ancestor filter is not supported for %ss", resource)
+	}
+	if f.Dangling != nil && !spec.dangling {
+		return fmt.Errorf("dangling filter is not supported for %ss", resource)
+	}
+	return nil
+}
+
+// dockerArgs 转换为 Docker API 的过滤器
+func (f ListFilter) dockerArgs(resource string) filters.Args {
+	args := filters.NewArgs()
+	for _, status := range f.Status {
+		args.Add("status", status)
+	}
+	for _, req := range f.Labels {
+		switch req.op {
+		case "exists":
+			args.Add("label", req.key)
+		case "=":
+			args.Add("label", req.key+"="+req.value)
+		}
+	}
+	for _, name := range f.Name {
+		args.Add(listResources[resource].nameFilter, name)
+	}
+	for _, ancestor := range f.Ancestor {
+		args.Add("ancestor", ancestor)
+	}
+	if f.Dangling != nil {
+		args.Add("dangling", fmt.Sprint(*f.Dangling))
+	}
+	return args
+}
+
+// matchContainer 在本地判断容器是否满足条件，用于不支持过滤器的运行时
+func (f ListFilter) matchContainer(c ContainerInfo) bool {
+	if len(f.Status) > 0 && !contains(f.Status, c.State) {
+		return false
+	}
+	if !f.Labels.Matches(c.Labels) || !matchAnySubstring(c.Name, f.Name) {
+		return false
+	}
+	if len(f.Ancestor) == 0 {
+		return true
+	}
+	for _, ancestor := range f.Ancestor {
+		if sameImage(c.Image, ancestor) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchImage 在本地判断镜像是否满足条件，用于不支持过滤器的运行时
+func (f ListFilter) matchImage(image ImageInfo) bool {
+	if !f.Labels.Matches(image.Labels) {
+		return false
+	}
+	dangling := image.Repository == "<none>"
+	if f.Dangling != nil && *f.Dangling != dangling {
+		return false
+	}
+	if len(f.Name) == 0 {
+		return true
+	}
+	for _, pattern := range f.Name {
+		for _, name := range []string{image.Repository, image.Repository + ":" + image.Tag} {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchAnySubstring(value string, substrings []string) bool {
+	if len(substrings) == 0 {
+		return true
+	}
+	for _, s := range substrings {
+		if strings.Contains(value, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameImage 判断两个镜像引用是否指向同一镜像，ancestor 不带标签时匹配所有标签
+func sameImage(image, ancestor string) bool {
+	if image == ancestor {
+		return true
+	}
+	imageRef, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return false
+	}
+	ancestorRef, err := reference.ParseNormalizedNamed(ancestor)
+	if err != nil {
+		return false
+	}
+	if imageRef.Name() != ancestorRef.Name() {
+		return false
+	}
+	if reference.IsNameOnly(ancestorRef) {
+		return true
+	}
+	return reference.TagNameOnly(imageRef).String() == ancestorRef.String()
+}
+
+// ListOptions 列表的筛选、排序和分页参数
+type ListOptions struct {
+	Filter ListFilter
+	// Sort 排序字段，为空时按名称排序，名称相同时按 ID 排序
+	Sort string
+	Desc bool
+	// Limit 每页数量，为 0 时返回全部
+	Limit int
+	// Cursor 上一页返回的 NextCursor，为空时从第一页开始
+	Cursor string
+}
+
+// Validate 校验某类资源的列表参数
+func (o ListOptions) Validate(resource string) error {
+	spec, ok := listResources[resource]
+	if !ok {
+		return fmt.Errorf("unknown resource %q", resource)
+	}
+	if o.Sort != "" && !contains(spec.sortFields, o.Sort) {
+		return fmt.Errorf("invalid sort %q: must be one of %s", o.Sort, strings.Join(spec.sortFields, ", "))
+	}
+	if o.Limit < 0 || o.Limit > MaxListLimit {
+		return fmt.Errorf("limit must be between 0 and %d", MaxListLimit)
+	}
+	if o.Cursor != "" {
+		if _, err := o.decodeCursor(); err != nil {
+			return err
+		}
+	}
+	return o.Filter.validate(resource, spec)
+}
+
+func (o ListOptions) sortField() string {
+	if o.Sort == "" {
+		return SortByName
+	}
+	return o.Sort
+}
+
+// ListPage 一页列表结果
+type ListPage[T any] struct {
+	Items []T
+	// Total 满足筛选条件的总数
+	Total int
+	// NextCursor 用于获取下一页，为空表示没有下一页
+	NextCursor string
+}
+
+// sortKey 排序键，数值字段使用 Num，字符串字段使用 Str，ID 用于打破平局
+type sortKey struct {
+	Str string `json:"s,omitempty"`
+	Num int64  `json:"n,omitempty"`
+	ID  string `json:"id"`
+}
+
+func (k sortKey) compare(other sortKey) int {
+	switch {
+	case k.Num != other.Num:
+		if k.Num < other.Num {
+			return -1
+		}
+		return 1
+	case k.Str != other.Str:
+		return strings.Compare(k.Str, other.Str)
+	default:
+		return strings.Compare(k.ID, other.ID)
+	}
+}
+
+// listCursor 分页游标，记录上一页最后一项的排序键。
+// 按排序键而不是偏移量定位，翻页期间删除资源不会导致后续页重复或跳过
+type listCursor struct {
+	Sort string  `json:"sort"`
+	Desc bool    `json:"desc,omitempty"`
+	Key  sortKey `json:"key"`
+}
+
+func (o ListOptions) encodeCursor(key sortKey) string {
+	data, _ := json.Marshal(listCursor{Sort: o.sortField(), Desc: o.Desc, Key: key})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (o ListOptions) decodeCursor() (sortKey, error) {
+	data, err := base64.RawURLEncoding.DecodeString(o.Cursor)
+	if err != nil {
+		return sortKey{}, fmt.Errorf("invalid cursor")
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return sortKey{}, fmt.Errorf("invalid cursor")
+	}
+	if cursor.Sort != o.sortField() || cursor.Desc != o.Desc {
+		return sortKey{}, fmt.Errorf("cursor does not match sort %s", o.sortField())
+	}
+	return cursor.Key, nil
+}
+
+// paginate 按 opts 排序并返回游标之后的一页
+func paginate[T any](items []T, opts ListOptions, key func(item T, field string) sortKey) (*ListPage[T], error) {
+	field := opts.sortField()
+	keys := make([]sortKey, len(items))
+	order := make([]int, len(items))
+	for i, item := range items {
+		keys[i] = key(item, field)
+		order[i] = i
+	}
+	// less 按排序方向比较，降序时反转
+	less := func(a, b sortKey) bool {
+		if opts.Desc {
+			return a.compare(b) > 0
+		}
+		return a.compare(b) < 0
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return less(keys[order[i]], keys[order[j]])
+	})
+
+	start := 0
+	if opts.Cursor != "" {
+		after, err := opts.decodeCursor()
+		if err != nil {
+			return nil, err
+		}
+		start = sort.Search(len(order), func(i int) bool {
+			return less(after, keys[order[i]])
+		})
+	}
+	end := len(order)
+	page := &ListPage[T]{Total: len(items)}
+	if opts.Limit > 0 && start+opts.Limit < end {
+		end = start + opts.Limit
+		page.NextCursor = opts.encodeCursor(keys[order[end-1]])
+	}
+	for _, i := range order[start:end] {
+		page.Items = append(page.Items, items[i])
+	}
+	return page, nil
+}
+
+// filterLabels 在本地应用 daemon 不支持的标签条件
+func filterLabels[T any](items []T, selector LabelSelector, labels func(T) map[string]string) []T {
+	if len(selector) == 0 {
+		return items
+	}
+	var filtered []T
+	for _, item := range items {
+		if selector.Matches(labels(item)) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+func containerSortKey(c ContainerInfo, field string) sortKey {
+	key := sortKey{ID: c.ID}
+	switch field {
+	case SortByCreated:
+		key.Num = c.Created
+	case SortByState:
+		key.Str = c.State
+	case SortByImage:
+		key.Str = c.Image
+	default:
+		key.Str = c.Name
+	}
+	return key
+}
+
+func imageSortKey(image ImageInfo, field string) sortKey {
+	key := sortKey{ID: image.ID}
+	switch field {
+	case SortByCreated:
+		key.Num = image.Created
+	case SortBySize:
+		key.Num = image.Size
+	default:
+		key.Str = image.Repository + ":" + image.Tag
+	}
+	return key
+}
+
+func networkSortKey(network NetworkInfo, field string) sortKey {
+	key := sortKey{ID: network.ID}
+	switch field {
+	case SortByCreated:
+		key.Num = network.Created.UnixNano()
+	case SortByDriver:
+		key.Str = network.Driver
+	default:
+		key.Str = network.Name
+	}
+	return key
+}
+
+func volumeSortKey(volume VolumeInfo, field string) sortKey {
+	key := sortKey{ID: volume.Name}
+	switch field {
+	case SortByCreated:
+		// 部分驱动不返回创建时间，视为最早
+		if created, err := time.Parse(time.RFC3339, volume.CreatedAt); err == nil {
+			key.Num = created.UnixNano()
+		}
+	case SortByDriver:
+		key.Str = volume.Driver
+	}
+	return key
+}
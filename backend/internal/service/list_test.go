@@ -0,0 +1,221 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestListCursorRoundTrip(t *testing.T) {
+	opts := ListOptions{Sort: SortByCreated, Desc: true}
+	key := sortKey{Str: "web", Num: 1700000000, ID: "3f4e8c2a91b7"}
+	opts.Cursor = opts.encodeCursor(key)
+
+	got, err := opts.decodeCursor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != key {
+		t.Errorf("decodeCursor() = %+v, want %+v", got, key)
+	}
+	if err := opts.Validate(ResourceContainer); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+
+	// 游标与排序参数绑定
+	for _, other := range []ListOptions{
+		{Sort: SortByCreated, Cursor: opts.Cursor},
+		{Sort: SortByName, Desc: true, Cursor: opts.Cursor},
+		{Desc: true, Cursor: opts.Cursor},
+	} {
+		if _, err := other.decodeCursor(); err == nil {
+			t.Errorf("decodeCursor() with sort %q desc %v: expected error", other.Sort, other.Desc)
+		}
+	}
+	for _, cursor := range []string{"not base64!", "bm90IGpzb24"} {
+		if _, err := (ListOptions{Cursor: cursor}).decodeCursor(); err == nil {
+			t.Errorf("decodeCursor(%q): expected error", cursor)
+		}
+	}
+}
+
+// walkPages 按 limit 逐页读取，返回所有项的 ID
+func walkPages[T any](t *testing.T, items []T, opts ListOptions, key func(T, string) sortKey, id func(T) string) []string {
+	t.Helper()
+	var ids []string
+	for pages := 0; ; pages++ {
+		if pages > len(items) {
+			t.Fatal("pagination does not terminate")
+		}
+		page, err := paginate(items, opts, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != len(items) {
+			t.Errorf("Total = %d, want %d", page.Total, len(items))
+		}
+		if opts.Limit > 0 && len(page.Items) > opts.Limit {
+			t.Errorf("page has %d items, limit %d", len(page.Items), opts.Limit)
+		}
+		for _, item := range page.Items {
+			ids = append(ids, id(item))
+		}
+		if page.NextCursor == "" {
+			return ids
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+func reversed(ids []string) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[len(ids)-1-i] = id
+	}
+	return out
+}
+
+// checkSort 校验升序、降序以及不分页时的顺序
+func checkSort[T any](t *testing.T, items []T, field string, want []string, key func(T, string) sortKey, id func(T) string) {
+	t.Helper()
+	if got := walkPages(t, items, ListOptions{Sort: field, Limit: 3}, key, id); !reflect.DeepEqual(got, want) {
+		t.Errorf("sort %s asc = %v, want %v", field, got, want)
+	}
+	if got := walkPages(t, items, ListOptions{Sort: field, Desc: true, Limit: 3}, key, id); !reflect.DeepEqual(got, reversed(want)) {
+		t.Errorf("sort %s desc = %v, want %v", field, got, reversed(want))
+	}
+	if got := walkPages(t, items, ListOptions{Sort: field}, key, id); !reflect.DeepEqual(got, want) {
+		t.Errorf("sort %s without limit = %v, want %v", field, got, want)
+	}
+}
+
+func TestPaginateContainers(t *testing.T) {
+	containers := []ContainerInfo{
+		{ID: "c1", Name: "web", State: "running", Image: "nginx", Created: 30},
+		{ID: "c2", Name: "api", State: "exited", Image: "redis", Created: 10},
+		{ID: "c3", Name: "db", State: "running", Image: "alpine", Created: 20},
+		{ID: "c4", Name: "cache", State: "created", Image: "nginx", Created: 20},
+	}
+	id := func(c ContainerInfo) string { return c.ID }
+	tests := []struct {
+		field string
+		want  []string
+	}{
+		{"", []string{"c2", "c4", "c3", "c1"}},
+		{SortByName, []string{"c2", "c4", "c3", "c1"}},
+		// 排序字段相同时按 ID 排序
+		{SortByCreated, []string{"c2", "c3", "c4", "c1"}},
+		{SortByState, []string{"c4", "c2", "c1", "c3"}},
+		{SortByImage, []string{"c3", "c1", "c4", "c2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			checkSort(t, containers, tt.field, tt.want, containerSortKey, id)
+		})
+	}
+}
+
+func TestPaginateImages(t *testing.T) {
+	images := []ImageInfo{
+		{ID: "i1", Repository: "nginx", Tag: "latest", Size: 100, Created: 3},
+		{ID: "i2", Repository: "alpine", Tag: "3", Size: 50, Created: 1},
+		{ID: "i3", Repository: "redis", Tag: "7", Size: 200, Created: 2},
+		{ID: "i4", Repository: "<none>", Tag: "<none>", Size: 50, Created: 1},
+	}
+	id := func(image ImageInfo) string { return image.ID }
+	tests := []struct {
+		field string
+		want  []string
+	}{
+		{SortByName, []string{"i4", "i2", "i1", "i3"}},
+		{SortByCreated, []string{"i2", "i4", "i3", "i1"}},
+		{SortBySize, []string{"i2", "i4", "i1", "i3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			checkSort(t, images, tt.field, tt.want, imageSortKey, id)
+		})
+	}
+}
+
+func TestPaginateNetworks(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	networks := []NetworkInfo{
+		{ID: "n1", Name: "bridge", Driver: "bridge", Created: base.Add(2 * time.Hour)},
+		{ID: "n2", Name: "app", Driver: "overlay", Created: base.Add(time.Hour)},
+		{ID: "n3", Name: "host", Driver: "host", Created: base},
+		{ID: "n4", Name: "none", Driver: "null", Created: base.Add(3 * time.Hour)},
+	}
+	id := func(network NetworkInfo) string { return network.ID }
+	tests := []struct {
+		field string
+		want  []string
+	}{
+		{SortByName, []string{"n2", "n1", "n3", "n4"}},
+		{SortByCreated, []string{"n3", "n2", "n1", "n4"}},
+		{SortByDriver, []string{"n1", "n3", "n4", "n2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			checkSort(t, networks, tt.field, tt.want, networkSortKey, id)
+		})
+	}
+}
+
+func TestPaginateVolumes(t *testing.T) {
+	volumes := []VolumeInfo{
+		{Name: "data", Driver: "local", CreatedAt: "2024-01-02T00:00:00Z"},
+		{Name: "logs", Driver: "nfs", CreatedAt: "2024-01-01T00:00:00Z"},
+		// 没有创建时间的数据卷视为最早
+		{Name: "cache", Driver: "local"},
+		{Name: "backup", Driver: "local", CreatedAt: "2024-01-03T00:00:00Z"},
+	}
+	id := func(volume VolumeInfo) string { return volume.Name }
+	tests := []struct {
+		field string
+		want  []string
+	}{
+		{SortByName, []string{"backup", "cache", "data", "logs"}},
+		{SortByCreated, []string{"cache", "logs", "data", "backup"}},
+		{SortByDriver, []string{"backup", "cache", "data", "logs"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			checkSort(t, volumes, tt.field, tt.want, volumeSortKey, id)
+		})
+	}
+}
+
+func TestPaginateCursorAfterRemovedItem(t *testing.T) {
+	containers := []ContainerInfo{
+		{ID: "c1", Name: "a"},
+		{ID: "c2", Name: "b"},
+		{ID: "c3", Name: "c"},
+		{ID: "c4", Name: "d"},
+	}
+	opts := ListOptions{Limit: 2}
+	page, err := paginate(containers, opts, containerSortKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.NextCursor == "" {
+		t.Fatal("expected a next cursor")
+	}
+
+	// 删除上一页的最后一项后，下一页仍从其后开始，不重复也不跳过
+	opts.Cursor = page.NextCursor
+	page, err = paginate([]ContainerInfo{containers[0], containers[2], containers[3]}, opts, containerSortKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, c := range page.Items {
+		ids = append(ids, c.ID)
+	}
+	if want := []string{"c3", "c4"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("next page = %v, want %v", ids, want)
+	}
+	if page.NextCursor != "" {
+		t.Errorf("NextCursor = %q, want empty", page.NextCursor)
+	}
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 日志来源
+const (
+	LogStreamStdout = "stdout"
+	LogStreamStderr = "stderr"
+)
+
+// DefaultLogTail 未指定 tail 时返回的行数
+const DefaultLogTail = "1000"
+
+// maxLogLineSize 单行日志的最大长度，超过时拆成多行返回，避免压缩后的 JSON 等超长的行中断读取
+const maxLogLineSize = 1024 * 1024
+
+// LogOptions 读取容器日志的参数
+type LogOptions struct {
+	// Follow 读取完已有日志后继续等待新日志，直到 ctx 取消或容器停止
+	Follow bool
+	// Since、Until 为 RFC 3339 时间、Unix 时间戳或相对现在的时长（如 10m）
+	Since string
+	Until string
+	// Tail 从末尾返回的行数，all 表示全部，为空时使用 DefaultLogTail
+	Tail string
+	// Timestamps 返回每行的时间戳
+	Timestamps bool
+	Stdout     bool
+	Stderr     bool
+	// Grep 只返回包含该字符串的行，Regex 只返回匹配该正则表达式的行，同时指定时需同时满足
+	Grep  string
+	Regex string
+}
+
+// Validate 校验日志参数
+func (o LogOptions) Validate() error {
+	if !o.Stdout && !o.Stderr {
+		return fmt.Errorf("at least one of stdout and stderr is required")
+	}
+	for _, t := range []struct {
+		name  string
+		value string
+	}{
+		{"since", o.Since},
+		{"until", o.Until},
+	} {
+		if t.value != "" && !validLogTime(t.value) {
+			return fmt.Errorf("invalid %s %q: must be an RFC 3339 time, a Unix timestamp or a duration", t.name, t.value)
+		}
+	}
+	if o.Tail != "" && o.Tail != "all" {
+		if n, err := strconv.Atoi(o.Tail); err != nil || n < 0 {
+			return fmt.Errorf("invalid tail %q: must be all or a non-negative integer", o.Tail)
+		}
+	}
+	if o.Regex != "" {
+		if _, err := regexp.Compile(o.Regex); err != nil {
+			return fmt.Errorf("invalid regex %q: %v", o.Regex, err)
+		}
+	}
+	return nil
+}
+
+// validLogTime 判断是否为 Docker 接受的时间格式
+func validLogTime(value string) bool {
+	if _, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return true
+	}
+	if _, err := time.ParseDuration(value); err == nil {
+		return true
+	}
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
+
+func (o LogOptions) tail() string {
+	if o.Tail == "" {
+		return DefaultLogTail
+	}
+	return o.Tail
+}
+
+// matcher 返回按 Grep 和 Regex 过滤日志内容的函数，参数已经过 Validate 校验
+func (o LogOptions) matcher() func(text string) bool {
+	var re *regexp.Regexp
+	if o.Regex != "" {
+		re = regexp.MustCompile(o.Regex)
+	}
+	return func(text string) bool {
+		if o.Grep != "" && !strings.Contains(text, o.Grep) {
+			return false
+		}
+		return re == nil || re.MatchString(text)
+	}
+}
+
+// LogLine 一行日志
+type LogLine struct {
+	Stream string `json:"stream"`
+	// Timestamp 为 RFC 3339 格式，只在请求时间戳时返回
+	Timestamp string `json:"timestamp,omitempty"`
+	Text      string `json:"text"`
+}
+
+// String 转换为 docker logs 的输出格式，时间戳在行首
+func (l LogLine) String() string {
+	if l.Timestamp == "" {
+		return l.Text
+	}
+	return l.Timestamp + " " + l.Text
+}
+
+// StreamContainerLogs 按 opts 读取容器日志，每行调用一次 fn，fn 返回错误时停止读取并返回该错误
+func (s *DockerService) StreamContainerLogs(ctx context.Context, contextName string, id string, opts LogOptions, fn func(LogLine) error) (err error) {
+	defer logCall(ctx, contextName, "ContainerLogs", time.Now(), &err)
+
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	rt, release, err := s.getRuntime(contextName)
+	if err != nil {
+		return err
+	}
+	defer release()
+	match := opts.matcher()
+	err = rt.ContainerLogs(ctx, id, opts, func(line LogLine) error {
+		if !match(line.Text) {
+			return nil
+		}
+		if !opts.Timestamps {
+			line.Timestamp = ""
+		}
+		return fn(line)
+	})
+	if opts.Follow && ctx.Err() != nil {
+		// 跟随模式下客户端断开连接是正常结束
+		return nil
+	}
+	return err
+}
+
+// readDockerLogs 读取 Docker 日志流。非 TTY 容器的 stdout 和 stderr 复用同一连接，
+// 每帧前有 8 字节的头：第 1 字节为来源，后 4 字节为大端序的长度；TTY 容器的日志没有帧头，都视为 stdout。
+// Docker 按请求在每行开头加上时间戳
+func readDockerLogs(r io.Reader, tty bool, fn func(LogLine) error) error {
+	if tty {
+		reader := bufio.NewReaderSize(r, 64*1024)
+		var line []byte
+		// split 上一次因超长拆出了前半行，此时行尾恰好在拆分处的空内容不是新的一行
+		split := false
+		for {
+			chunk, isPrefix, err := reader.ReadLine()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			line = append(line, chunk...)
+			if isPrefix && len(line) < maxLogLineSize {
+				continue
+			}
+			if len(line) > 0 || !split {
+				if err := fn(parseLogLine(LogStreamStdout, string(line))); err != nil {
+					return err
+				}
+			}
+			split = isPrefix
+			line = line[:0]
+		}
+	}
+
+	// 超长的行会被拆成多帧，按来源缓存未结束的行
+	pending := map[string]*bytes.Buffer{
+		LogStreamStdout: {},
+		LogStreamStderr: {},
+	}
+	flush := func(stream string, all bool) error {
+		buf := pending[stream]
+		for {
+			i := bytes.IndexByte(buf.Bytes(), '\n')
+			if i < 0 {
+				break
+			}
+			line := string(buf.Next(i + 1))
+			if err := fn(parseLogLine(stream, strings.TrimSuffix(line, "\n"))); err != nil {
+				return err
+			}
+		}
+		// 没有换行的超长内容先按 maxLogLineSize 拆出，不无限缓存
+		for buf.Len() >= maxLogLineSize {
+			if err := fn(parseLogLine(stream, string(buf.Next(maxLogLineSize)))); err != nil {
+				return err
+			}
+		}
+		if all && buf.Len() > 0 {
+			line := buf.String()
+			buf.Reset()
+			return fn(parseLogLine(stream, line))
+		}
+		return nil
+	}
+
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		size := binary.BigEndian.Uint32(header[4:])
+		var stream string
+		switch header[0] {
+		case 1:
+			stream = LogStreamStdout
+		case 2:
+			stream = LogStreamStderr
+		case 3:
+			// daemon 读取日志出错时写入 systemerr 帧
+			message, _ := io.ReadAll(io.LimitReader(r, int64(size)))
+			return fmt.Errorf("error from daemon: %s", message)
+		default:
+			return fmt.Errorf("invalid log stream %d", header[0])
+		}
+		if _, err := io.CopyN(pending[stream], r, int64(size)); err != nil {
+			return err
+		}
+		if err := flush(stream, false); err != nil {
+			return err
+		}
+	}
+	if err := flush(LogStreamStdout, true); err != nil {
+		return err
+	}
+	return flush(LogStreamStderr, true)
+}
+
+// parseLogLine 拆分 Docker 在行首加的时间戳
+func parseLogLine(stream, line string) LogLine {
+	line = strings.TrimSuffix(line, "\r")
+	if ts, text, ok := strings.Cut(line, " "); ok {
+		if _, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			return LogLine{Stream: stream, Timestamp: ts, Text: text}
+		}
+	}
+	return LogLine{Stream: stream, Text: line}
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadDockerLogsTTYLongLine(t *testing.T) {
+	long := strings.Repeat("x", maxLogLineSize+10)
+	input := "2024-01-01T00:00:01Z short\r\n2024-01-01T00:00:02Z " + long + "\r\n" +
+		strings.Repeat("y", maxLogLineSize) + "\nend"
+
+	var lines []LogLine
+	err := readDockerLogs(strings.NewReader(input), true, func(line LogLine) error {
+		lines = append(lines, line)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []int
+	for _, line := range lines {
+		got = append(got, len(line.String()))
+	}
+	// 超长的行按 maxLogLineSize 拆分，恰好等于上限的行不产生多余的空行
+	want := []int{len("2024-01-01T00:00:01Z short"), maxLogLineSize, len("2024-01-01T00:00:02Z ") + 10, maxLogLineSize, len("end")}
+	if len(got) != len(want) {
+		t.Fatalf("line lengths = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("line lengths = %v, want %v", got, want)
+		}
+	}
+	if lines[0].Timestamp != "2024-01-01T00:00:01Z" || lines[0].Text != "short" {
+		t.Errorf("first line = %+v", lines[0])
+	}
+}
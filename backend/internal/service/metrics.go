@@ -0,0 +1,531 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// metricsConcurrency 每个 context 同时采样的容器数量
+	metricsConcurrency = 8
+	// metricsPersistInterval 打开持久化时定期保存的间隔，服务退出时也会保存
+	metricsPersistInterval = 5 * time.Minute
+	// metricsFileVersion 持久化文件的格式版本
+	metricsFileVersion = 1
+)
+
+var (
+	// ErrMetricsDisabled 未开启历史统计
+	ErrMetricsDisabled = errors.New("metrics collection is disabled")
+	// ErrNoMetrics 没有该容器的历史统计，容器未运行过或不在采样的 context 中
+	ErrNoMetrics = errors.New("no metrics for container")
+)
+
+// MetricsConfig 历史统计的采样和保留配置。
+// 最近 RawRetention 内保留每次采样，更早的数据按 Resolution 取平均后保留到 Retention
+type MetricsConfig struct {
+	// Interval 采样间隔
+	Interval time.Duration
+	// Selector 选择采样的 context，为空时采样所有 context
+	Selector     ContextSelector
+	RawRetention time.Duration
+	Resolution   time.Duration
+	Retention    time.Duration
+	// PersistPath 持久化文件路径，为空时只保存在内存中
+	PersistPath string
+}
+
+// Validate 校验配置
+func (c MetricsConfig) Validate() error {
+	if c.Interval < time.Second {
+		return fmt.Errorf("metrics interval must be at least 1s")
+	}
+	if c.RawRetention < c.Interval {
+		return fmt.Errorf("metrics raw retention must be at least the sampling interval %s", c.Interval)
+	}
+	if c.Resolution < c.Interval {
+		return fmt.Errorf("metrics resolution must be at least the sampling interval %s", c.Interval)
+	}
+	if c.Retention < c.RawRetention {
+		return fmt.Errorf("metrics retention must be at least the raw retention %s", c.RawRetention)
+	}
+	return nil
+}
+
+// MetricPoint 一个采样点或一段时间的平均值。
+// 网络和块设备 IO 为累计值，取时间段内最后一次采样的值
+type MetricPoint struct {
+	Time          time.Time `json:"time"`
+	CPUPercent    float64   `json:"cpuPercent"`
+	MemoryUsage   uint64    `json:"memoryUsage"`
+	MemoryLimit   uint64    `json:"memoryLimit"`
+	MemoryPercent float64   `json:"memoryPercent"`
+	NetworkRx     uint64    `json:"networkRx"`
+	NetworkTx     uint64    `json:"networkTx"`
+	BlockRead     uint64    `json:"blockRead"`
+	BlockWrite    uint64    `json:"blockWrite"`
+	PIDs          uint64    `json:"pids"`
+}
+
+func metricPoint(t time.Time, stats ContainerStats) MetricPoint {
+	return MetricPoint{
+		Time:          t,
+		CPUPercent:    stats.CPUPercent,
+		MemoryUsage:   stats.MemoryUsage,
+		MemoryLimit:   stats.MemoryLimit,
+		MemoryPercent: stats.MemoryPercent,
+		NetworkRx:     stats.NetworkRx,
+		NetworkTx:     stats.NetworkTx,
+		BlockRead:     stats.BlockRead,
+		BlockWrite:    stats.BlockWrite,
+		PIDs:          stats.PIDs,
+	}
+}
+
+// metricRing 固定容量的环形缓冲区，写满后覆盖最早的点
+type metricRing struct {
+	points []MetricPoint
+	// next 下一个写入位置，full 表示已写满
+	next int
+	full bool
+}
+
+func newMetricRing(capacity int) *metricRing {
+	return &metricRing{points: make([]MetricPoint, capacity)}
+}
+
+func (r *metricRing) push(p MetricPoint) {
+	r.points[r.next] = p
+	r.next = (r.next + 1) % len(r.points)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// all 按时间顺序返回所有点
+func (r *metricRing) all() []MetricPoint {
+	if !r.full {
+		return append([]MetricPoint(nil), r.points[:r.next]...)
+	}
+	return append(append([]MetricPoint(nil), r.points[r.next:]...), r.points[:r.next]...)
+}
+
+// metricBucket 正在累积的降采样时间段
+type metricBucket struct {
+	start time.Time
+	count int
+	sum   MetricPoint
+	last  MetricPoint
+}
+
+func (b *metricBucket) add(p MetricPoint) {
+	b.count++
+	b.sum.CPUPercent += p.CPUPercent
+	b.sum.MemoryUsage += p.MemoryUsage
+	b.sum.MemoryPercent += p.MemoryPercent
+	b.sum.PIDs += p.PIDs
+	b.last = p
+}
+
+// average 时间段的平均值，时间为时间段的起点
+func (b *metricBucket) average() MetricPoint {
+	n := uint64(b.count)
+	return MetricPoint{
+		Time:          b.start,
+		CPUPercent:    b.sum.CPUPercent / float64(b.count),
+		MemoryUsage:   b.sum.MemoryUsage / n,
+		MemoryLimit:   b.last.MemoryLimit,
+		MemoryPercent: b.sum.MemoryPercent / float64(b.count),
+		NetworkRx:     b.last.NetworkRx,
+		NetworkTx:     b.last.NetworkTx,
+		BlockRead:     b.last.BlockRead,
+		BlockWrite:    b.last.BlockWrite,
+		PIDs:          b.sum.PIDs / n,
+	}
+}
+
+// metricSeries 一个容器的历史统计
+type metricSeries struct {
+	context string
+	id      string
+	name    string
+	last    time.Time
+
+	raw         *metricRing
+	downsampled *metricRing
+	bucket      *metricBucket
+}
+
+// add 写入一个采样点，进入新的时间段时将上一段的平均值写入降采样缓冲区
+func (s *metricSeries) add(p MetricPoint, resolution time.Duration) {
+	s.raw.push(p)
+	s.last = p.Time
+	start := p.Time.Truncate(resolution)
+	if s.bucket != nil && !s.bucket.start.Equal(start) {
+		s.downsampled.push(s.bucket.average())
+		s.bucket = nil
+	}
+	if s.bucket == nil {
+		s.bucket = &metricBucket{start: start}
+	}
+	s.bucket.add(p)
+}
+
+// points 返回 [from, to] 内的点：原始采样覆盖的时间段使用原始采样，更早的使用降采样的平均值
+func (s *metricSeries) points(from, to time.Time, resolution time.Duration) []MetricPoint {
+	raw := s.raw.all()
+	result := []MetricPoint{}
+	for _, p := range s.downsampled.all() {
+		// 与原始采样重叠的时间段只使用原始采样
+		if len(raw) > 0 && !p.Time.Before(raw[0].Time.Truncate(resolution)) {
+			break
+		}
+		if inRange(p.Time, from, to) {
+			result = append(result, p)
+		}
+	}
+	for _, p := range raw {
+		if inRange(p.Time, from, to) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+func inRange(t, from, to time.Time) bool {
+	return (from.IsZero() || !t.Before(from)) && (to.IsZero() || !t.After(to))
+}
+
+// metricsStore 保存各 context 中容器的历史统计
+type metricsStore struct {
+	mu     sync.RWMutex
+	config *MetricsConfig
+	series map[string]*metricSeries
+}
+
+func seriesKey(contextName, id string) string {
+	return contextName + "/" + id
+}
+
+func (m *metricsStore) newSeries(contextName, id, name string) *metricSeries {
+	cfg := m.config
+	return &metricSeries{
+		context:     contextName,
+		id:          id,
+		name:        name,
+		raw:         newMetricRing(ringCapacity(cfg.RawRetention, cfg.Interval)),
+		downsampled: newMetricRing(ringCapacity(cfg.Retention, cfg.Resolution)),
+	}
+}
+
+func ringCapacity(retention, step time.Duration) int {
+	return int((retention + step - 1) / step)
+}
+
+func (m *metricsStore) add(contextName string, t time.Time, stats ContainerStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := seriesKey(contextName, stats.ID)
+	series, ok := m.series[key]
+	if !ok {
+		series = m.newSeries(contextName, stats.ID, stats.Name)
+		m.series[key] = series
+	}
+	series.name = stats.Name
+	series.add(metricPoint(t, stats), m.config.Resolution)
+}
+
+// prune 删除超过保留时间没有新采样的容器（通常已删除）
+func (m *metricsStore) prune(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, series := range m.series {
+		if now.Sub(series.last) > m.config.Retention {
+			delete(m.series, key)
+		}
+	}
+}
+
+// MonitorMetrics 按配置的间隔采样所选 context 中所有运行中容器的资源统计，直到 ctx 取消
+func (s *DockerService) MonitorMetrics(ctx context.Context, config MetricsConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	s.metrics.mu.Lock()
+	s.metrics.config = &config
+	s.metrics.series = make(map[string]*metricSeries)
+	s.metrics.mu.Unlock()
+
+	if config.PersistPath != "" {
+		if err := s.loadMetrics(config.PersistPath); err != nil {
+			slog.Warn("failed to load metrics", "path", config.PersistPath, "error", err)
+		}
+		defer func() {
+			if err := s.saveMetrics(config.PersistPath); err != nil {
+				slog.Warn("failed to save metrics", "path", config.PersistPath, "error", err)
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(config.Interval)
+	defer ticker.Stop()
+	lastSave := time.Now()
+	for {
+		s.sampleMetrics(ctx, config)
+		if config.PersistPath != "" && time.Since(lastSave) >= metricsPersistInterval {
+			if err := s.saveMetrics(config.PersistPath); err != nil {
+				slog.Warn("failed to save metrics", "path", config.PersistPath, "error", err)
+			}
+			lastSave = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sampleMetrics 并发采样所有所选 context，每个 context 的采样不超过一个采样间隔
+func (s *DockerService) sampleMetrics(ctx context.Context, config MetricsConfig) {
+	names, err := s.selectContexts(config.Selector)
+	if err != nil {
+		slog.Warn("failed to load contexts for metrics", "error", err)
+		return
+	}
+	now := time.Now()
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			sampleCtx, cancel := context.WithTimeout(ctx, config.Interval)
+			defer cancel()
+			if err := s.sampleContext(sampleCtx, name, now); err != nil && ctx.Err() == nil {
+				slog.Debug("failed to sample metrics", "context", name, "error", err)
+			}
+		}(name)
+	}
+	wg.Wait()
+	s.metrics.prune(now)
+}
+
+// sampleContext 采样一个 context 中所有运行中的容器，单个容器失败时跳过
+func (s *DockerService) sampleContext(ctx context.Context, contextName string, now time.Time) error {
+	rt, release, err := s.getRuntime(contextName)
+	if err != nil {
+		return err
+	}
+	defer release()
+	containers, err := rt.ListContainers(ctx, ListFilter{Status: []string{"running"}})
+	if err != nil {
+		return err
+	}
+	sem := make(chan struct{}, metricsConcurrency)
+	var wg sync.WaitGroup
+	for _, c := range containers {
+		wg.Add(1)
+		go func(c ContainerInfo) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			err := rt.ContainerStats(ctx, c.ID, false, func(stats ContainerStats) {
+				stats.ID = c.ID
+				stats.Name = c.Name
+				// 同一轮的采样使用相同的时间，便于对齐多个容器的图表
+				s.metrics.add(contextName, now, stats)
+			})
+			if err != nil && !errors.Is(err, ErrNotSupported) {
+				slog.Debug("failed to sample container metrics", "context", contextName, "container", c.Name, "error", err)
+			}
+		}(c)
+	}
+	wg.Wait()
+	return nil
+}
+
+// MetricSeriesInfo 一个容器历史统计的概要
+type MetricSeriesInfo struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// LastSample 最近一次采样的时间
+	LastSample time.Time `json:"lastSample"`
+}
+
+// ContainerMetrics 一个容器在查询时间范围内的历史统计
+type ContainerMetrics struct {
+	Context string `json:"context"`
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	// Interval 原始采样的间隔，Resolution 为较早数据的降采样间隔，单位为秒
+	Interval   int64         `json:"interval"`
+	Resolution int64         `json:"resolution"`
+	Points     []MetricPoint `json:"points"`
+}
+
+// ListMetricSeries 列出 context 中有历史统计的容器，按名称排序
+func (s *DockerService) ListMetricSeries(contextName string) ([]MetricSeriesInfo, error) {
+	s.metrics.mu.RLock()
+	defer s.metrics.mu.RUnlock()
+	if s.metrics.config == nil {
+		return nil, ErrMetricsDisabled
+	}
+	result := []MetricSeriesInfo{}
+	for _, series := range s.metrics.series {
+		if series.context == contextName {
+			result = append(result, MetricSeriesInfo{ID: series.id, Name: series.name, LastSample: series.last})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// GetContainerMetrics 查询容器在 [from, to] 内的历史统计，from 或 to 为零值时不限制。
+// id 可以是容器 ID、唯一的 ID 前缀或名称
+func (s *DockerService) GetContainerMetrics(contextName, id string, from, to time.Time) (*ContainerMetrics, error) {
+	s.metrics.mu.RLock()
+	defer s.metrics.mu.RUnlock()
+	if s.metrics.config == nil {
+		return nil, ErrMetricsDisabled
+	}
+	series := s.metrics.find(contextName, id)
+	if series == nil {
+		return nil, fmt.Errorf("%w %s", ErrNoMetrics, id)
+	}
+	return &ContainerMetrics{
+		Context:    contextName,
+		ID:         series.id,
+		Name:       series.name,
+		Interval:   int64(s.metrics.config.Interval / time.Second),
+		Resolution: int64(s.metrics.config.Resolution / time.Second),
+		Points:     series.points(from, to, s.metrics.config.Resolution),
+	}, nil
+}
+
+// find 按 ID、名称或唯一的 ID 前缀查找容器，调用方需持有读锁
+func (m *metricsStore) find(contextName, id string) *metricSeries {
+	if series, ok := m.series[seriesKey(contextName, id)]; ok {
+		return series
+	}
+	var match *metricSeries
+	for _, series := range m.series {
+		if series.context != contextName {
+			continue
+		}
+		if series.name == id {
+			return series
+		}
+		if strings.HasPrefix(series.id, id) {
+			if match != nil && match != series {
+				return nil
+			}
+			match = series
+		}
+	}
+	return match
+}
+
+// metricsFile 持久化文件的格式
+type metricsFile struct {
+	Version int               `json:"version"`
+	Series  []persistedSeries `json:"series"`
+}
+
+type persistedSeries struct {
+	Context     string        `json:"context"`
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Raw         []MetricPoint `json:"raw"`
+	Downsampled []MetricPoint `json:"downsampled"`
+}
+
+// saveMetrics 将历史统计写入临时文件后原子地替换持久化文件。
+// 正在累积的时间段不保存，重启后该时间段的平均值只包含重启后的采样
+func (s *DockerService) saveMetrics(path string) error {
+	s.metrics.mu.RLock()
+	file := metricsFile{Version: metricsFileVersion, Series: []persistedSeries{}}
+	for _, series := range s.metrics.series {
+		file.Series = append(file.Series, persistedSeries{
+			Context:     series.context,
+			ID:          series.id,
+			Name:        series.name,
+			Raw:         series.raw.all(),
+			Downsampled: series.downsampled.all(),
+		})
+	}
+	s.metrics.mu.RUnlock()
+
+	content, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// loadMetrics 读取持久化的历史统计，丢弃已超过保留时间的点。
+// 保留时间或间隔变化后缓冲区容量随之变化，超出容量时保留最近的点
+func (s *DockerService) loadMetrics(path string) error {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var file metricsFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return err
+	}
+	if file.Version != metricsFileVersion {
+		return fmt.Errorf("unsupported metrics file version %d", file.Version)
+	}
+
+	s.metrics.mu.Lock()
+	defer s.metrics.mu.Unlock()
+	cfg := s.metrics.config
+	now := time.Now()
+	for _, persisted := range file.Series {
+		series := s.metrics.newSeries(persisted.Context, persisted.ID, persisted.Name)
+		for _, p := range persisted.Downsampled {
+			if now.Sub(p.Time) <= cfg.Retention {
+				series.downsampled.push(p)
+				series.last = p.Time
+			}
+		}
+		for _, p := range persisted.Raw {
+			if now.Sub(p.Time) <= cfg.RawRetention {
+				series.raw.push(p)
+				series.last = p.Time
+			}
+		}
+		if series.last.IsZero() {
+			continue
+		}
+		s.metrics.series[seriesKey(persisted.Context, persisted.ID)] = series
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// podRuntime 返回 context 的 pod 运行时，不支持 pod 的运行时返回 ErrNotSupported
+func (s *DockerService) podRuntime(contextName string) (PodRuntime, error) {
+	rt, release, err := s.getRuntime(contextName)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	pods, ok := rt.(PodRuntime)
+	if !ok {
+		return nil, fmt.Errorf("pods: %w (%s)", ErrNotSupported, rt.Name())
+	}
+	return pods, nil
+}
+
+// ListPods 列出 Podman context 中的 pod
+func (s *DockerService) ListPods(ctx context.Context, contextName string) (pods []PodInfo, err error) {
+	defer logCall(ctx, contextName, "PodList", time.Now(), &err)
+
+	rt, err := s.podRuntime(contextName)
+	if err != nil {
+		return nil, err
+	}
+	return rt.ListPods(ctx)
+}
+
+func (s *DockerService) GetPodDetail(ctx context.Context, contextName string, id string) (detail map[string]any, err error) {
+	defer logCall(ctx, contextName, "PodInspect", time.Now(), &err)
+
+	rt, err := s.podRuntime(contextName)
+	if err != nil {
+		return nil, err
+	}
+	return rt.GetPodDetail(ctx, id)
+}
+
+func (s *DockerService) StartPod(ctx context.Context, contextName string, id string) (err error) {
+	defer logCall(ctx, contextName, "PodStart", time.Now(), &err)
+
+	rt, err := s.podRuntime(contextName)
+	if err != nil {
+		return err
+	}
+	return rt.StartPod(ctx, id)
+}
+
+func (s *DockerService) StopPod(ctx context.Context, contextName string, id string) (err error) {
+	defer logCall(ctx, contextName, "PodStop", time.Now(), &err)
+
+	rt, err := s.podRuntime(contextName)
+	if err != nil {
+		return err
+	}
+	return rt.StopPod(ctx, id)
+}
+
+func (s *DockerService) DeletePod(ctx context.Context, contextName string, id string, force bool) (err error) {
+	defer logCall(ctx, contextName, "PodRemove", time.Now(), &err)
+
+	rt, err := s.podRuntime(contextName)
+	if err != nil {
+		return err
+	}
+	return rt.DeletePod(ctx, id, force)
+}
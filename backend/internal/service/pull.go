@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+)
+
+// 创建容器时的镜像拉取策略
+const (
+	// PullNever 不拉取，镜像不存在时创建失败
+	PullNever = "never"
+	// PullMissing 本地没有镜像时拉取，与 docker run 的默认行为一致
+	PullMissing = "missing"
+	// PullAlways 总是拉取，用于获取标签的最新版本
+	PullAlways = "always"
+)
+
+// dockerHubAuthKey Docker CLI 的 config.json 中 Docker Hub 凭据的键
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+// RegistryAuth 镜像仓库凭据，IdentityToken 与用户名密码二选一
+type RegistryAuth struct {
+	Username      string
+	Password      string
+	IdentityToken string
+	// ServerAddress 仓库地址，为空时按镜像名推断
+	ServerAddress string
+}
+
+// PullOptions 拉取镜像的选项
+type PullOptions struct {
+	// Platform 如 linux/arm64，为空时由 daemon 决定
+	Platform string
+	Auth     *RegistryAuth
+}
+
+// PullProgress 拉取进度，对应 Docker 返回的一条进度消息
+type PullProgress struct {
+	// ID 镜像层 ID，整体状态（如 Digest、Status）没有 ID
+	ID       string `json:"id,omitempty"`
+	Status   string `json:"status"`
+	Progress string `json:"progress,omitempty"`
+	Current  int64  `json:"current,omitempty"`
+	Total    int64  `json:"total,omitempty"`
+}
+
+// ensureImage 按拉取策略在创建容器前准备镜像，progress 可以为空
+func (s *DockerService) ensureImage(ctx context.Context, rt Runtime, config ContainerConfig, progress func(PullProgress)) error {
+	switch config.Pull {
+	case "", PullNever:
+		return nil
+	case PullMissing:
+		exists, err := rt.HasImage(ctx, config.ImageID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return nil
+		}
+	}
+
+	auth := config.RegistryAuth
+	if auth == nil && s.cliRegistryAuth {
+		// 未提交凭据时使用 docker login 保存的凭据
+		var err error
+		if auth, err = dockerCLIRegistryAuth(config.ImageID); err != nil {
+			return err
+		}
+	}
+	if progress == nil {
+		progress = func(PullProgress) {}
+	}
+	return rt.PullImage(ctx, config.ImageID, PullOptions{Platform: config.Platform, Auth: auth}, progress)
+}
+
+// dockerCLIRegistryAuth 从 Docker CLI 的 config.json 读取镜像所在仓库的凭据，没有时返回 nil。
+// 只支持 docker login 直接保存在 auths 中的凭据，不支持 credsStore 等凭据助手
+func dockerCLIRegistryAuth(image string) (*RegistryAuth, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		// 镜像 ID 等无法解析为仓库地址的引用不需要凭据
+		return nil, nil
+	}
+	dockerDir, err := getDockerCLIDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dockerDir, "config.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var config struct {
+		Auths map[string]struct {
+			Auth          string `json:"auth"`
+			IdentityToken string `json:"identitytoken"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid docker config %s: %v", filepath.Join(dockerDir, "config.json"), err)
+	}
+
+	domain := reference.Domain(named)
+	keys := []string{domain, "https://" + domain, "http://" + domain}
+	if domain == "docker.io" {
+		keys = []string{dockerHubAuthKey}
+	}
+	for _, key := range keys {
+		entry, ok := config.Auths[key]
+		if !ok {
+			continue
+		}
+		auth := &RegistryAuth{ServerAddress: key, IdentityToken: entry.IdentityToken}
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("invalid docker credentials for %s: %v", key, err)
+			}
+			username, password, _ := strings.Cut(string(decoded), ":")
+			auth.Username = username
+			auth.Password = password
+		}
+		return auth, nil
+	}
+	return nil, nil
+}
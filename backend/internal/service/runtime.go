@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/volume"
+)
+
+// context 类型，tcp、socket、ssh 按 host 的协议连接 Docker，podman 和 containerd 选择对应的运行时
+const (
+	ContextTypeTCP        = "tcp"
+	ContextTypeSocket     = "socket"
+	ContextTypeSSH        = "ssh"
+	ContextTypePodman     = "podman"
+	ContextTypeContainerd = "containerd"
+)
+
+// 运行时名称
+const (
+	RuntimeDocker     = "docker"
+	RuntimePodman     = "podman"
+	RuntimeContainerd = "containerd"
+)
+
+// ErrNotSupported 运行时不支持该操作，如 containerd 没有网络和数据卷
+var ErrNotSupported = errors.New("operation not supported by runtime")
+
+// Runtime 一个 context 上的容器运行时。
+// 返回值沿用 Docker API 的类型，其他运行时的实现负责转换，不支持的操作返回 ErrNotSupported
+type Runtime interface {
+	// Name 运行时名称：docker、podman 或 containerd
+	Name() string
+	Ping(ctx context.Context) (types.Ping, error)
+	Info(ctx context.Context) (types.Info, error)
+
+	// List 系列方法按 filter 筛选，不支持的标签条件由调用方在本地筛选
+	ListContainers(ctx context.Context, filter ListFilter) ([]ContainerInfo, error)
+	GetContainerDetail(ctx context.Context, id string) (types.ContainerJSON, error)
+	CreateContainer(ctx context.Context, config ContainerConfig) (CreateContainerResult, error)
+	StartContainer(ctx context.Context, id string) error
+	// StopContainer 和 RestartContainer 的 timeout 为等待容器退出的秒数，-1 表示一直等待，nil 使用容器或运行时的默认值
+	StopContainer(ctx context.Context, id string, timeout *int) error
+	RestartContainer(ctx context.Context, id string, timeout *int) error
+	// KillContainer 向容器发送信号，signal 为空时发送 SIGKILL
+	KillContainer(ctx context.Context, id string, signal string) error
+	PauseContainer(ctx context.Context, id string) error
+	UnpauseContainer(ctx context.Context, id string) error
+	RenameContainer(ctx context.Context, id string, name string) error
+	UpdateContainer(ctx context.Context, id string, update ContainerUpdate) ([]string, error)
+	// WaitContainer 阻塞直到容器满足 condition：not-running、next-exit 或 removed
+	WaitContainer(ctx context.Context, id string, condition string) (ContainerWaitResult, error)
+	DeleteContainer(ctx context.Context, id string, force bool) error
+	// ContainerLogs 读取容器日志，每行调用一次 fn，fn 返回错误时停止读取
+	ContainerLogs(ctx context.Context, id string, opts LogOptions, fn func(LogLine) error) error
+	// ContainerStats 读取容器的资源统计，stream 为 false 时只调用一次 fn，为 true 时每次采样调用一次，直到 ctx 取消或容器停止
+	ContainerStats(ctx context.Context, id string, stream bool, fn func(ContainerStats)) error
+
+	CreateExec(ctx context.Context, containerID string, config types.ExecConfig) (types.IDResponse, error)
+	AttachExec(ctx context.Context, execID string, tty bool) (io.ReadWriteCloser, error)
+	StartExec(ctx context.Context, execID string, config types.ExecStartCheck) error
+	ResizeExec(ctx context.Context, execID string, height, width int) error
+
+	ListImages(ctx context.Context, filter ListFilter) ([]ImageInfo, error)
+	GetImageDetail(ctx context.Context, id string) (types.ImageInspect, error)
+	DeleteImage(ctx context.Context, id string) error
+	// HasImage 判断本地是否有该镜像
+	HasImage(ctx context.Context, ref string) (bool, error)
+	// PullImage 拉取镜像，每条进度消息调用一次 progress
+	PullImage(ctx context.Context, ref string, opts PullOptions, progress func(PullProgress)) error
+
+	ListNetworks(ctx context.Context, filter ListFilter) ([]NetworkInfo, error)
+	GetNetworkDetail(ctx context.Context, id string) (types.NetworkResource, error)
+	DeleteNetwork(ctx context.Context, id string) error
+
+	ListVolumes(ctx context.Context, filter ListFilter) ([]VolumeInfo, error)
+	GetVolumeDetail(ctx context.Context, name string) (volume.Volume, error)
+	DeleteVolume(ctx context.Context, name string) error
+
+	// Close 关闭连接，包括 ssh 类型 context 的 SSH 通道
+	Close() error
+}
+
+// PodRuntime 支持 pod 的运行时，目前只有 Podman
+type PodRuntime interface {
+	ListPods(ctx context.Context) ([]PodInfo, error)
+	GetPodDetail(ctx context.Context, id string) (map[string]any, error)
+	StartPod(ctx context.Context, id string) error
+	StopPod(ctx context.Context, id string) error
+	DeletePod(ctx context.Context, id string, force bool) error
+}
+
+// runtimeForType 返回 context 类型对应的运行时名称
+func runtimeForType(contextType string) string {
+	switch contextType {
+	case ContextTypePodman:
+		return RuntimePodman
+	case ContextTypeContainerd:
+		return RuntimeContainerd
+	default:
+		return RuntimeDocker
+	}
+}
+
+// validateContextType 校验 context 类型与 host 是否匹配
+func validateContextType(config ContextConfig) error {
+	switch config.Type {
+	case "", ContextTypeTCP, ContextTypeSocket, ContextTypeSSH, ContextTypePodman:
+		return nil
+	case ContextTypeContainerd:
+		if config.TLS != nil {
+			return fmt.Errorf("containerd contexts do not support tls")
+		}
+		if !strings.HasPrefix(config.Host, "unix://") && !strings.HasPrefix(config.Host, "ssh://") {
+			return fmt.Errorf("containerd host must be unix:///path/to/containerd.sock or ssh://user@host")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported context type %q", config.Type)
+	}
+}
+
+// newRuntime 按 context 类型创建运行时
+func (s *DockerService) newRuntime(contextName string, config ContextConfig) (Runtime, error) {
+	var (
+		rt  Runtime
+		err error
+	)
+	switch runtimeForType(config.Type) {
+	case RuntimePodman:
+		rt, err = s.newPodmanRuntime(contextName, config)
+	case RuntimeContainerd:
+		rt, err = s.newContainerdRuntime(contextName, config)
+	default:
+		rt, err = s.newDockerRuntime(contextName, config)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rt, nil
+}
+
+// withSSHSocket 为 ssh 类型的 context 设置远端默认的 socket 路径，已指定时不变
+func withSSHSocket(config ContextConfig, socketPath string) ContextConfig {
+	if !strings.HasPrefix(config.Host, "ssh://") {
+		return config
+	}
+	sshConfig := SSHConfig{}
+	if config.SSH != nil {
+		sshConfig = *config.SSH
+	}
+	if sshConfig.SocketPath == "" {
+		sshConfig.SocketPath = socketPath
+	}
+	config.SSH = &sshConfig
+	return config
+}
+
+// unsupportedRuntime 所有操作都返回 ErrNotSupported，供只实现部分操作的运行时嵌入
+type unsupportedRuntime struct {
+	name string
+}
+
+func (r unsupportedRuntime) notSupported(op string) error {
+	return fmt.Errorf("%s: %w (%s)", op, ErrNotSupported, r.name)
+}
+
+func (r unsupportedRuntime) Name() string { return r.name }
+
+func (r unsupportedRuntime) Ping(ctx context.Context) (types.Ping, error) {
+	return types.Ping{}, r.notSupported("ping")
+}
+
+func (r unsupportedRuntime) Info(ctx context.Context) (types.Info, error) {
+	return types.Info{}, r.notSupported("info")
+}
+
+func (r unsupportedRuntime) ListContainers(ctx context.Context, filter ListFilter) ([]ContainerInfo, error) {
+	return nil, r.notSupported("list containers")
+}
+
+func (r unsupportedRuntime) GetContainerDetail(ctx context.Context, id string) (types.ContainerJSON, error) {
+	return types.ContainerJSON{}, r.notSupported("inspect container")
+}
+
+func (r unsupportedRuntime) CreateContainer(ctx context.Context, config ContainerConfig) (CreateContainerResult, error) {
+	return CreateContainerResult{}, r.notSupported("create container")
+}
+
+func (r unsupportedRuntime) StartContainer(ctx context.Context, id string) error {
+	return r.notSupported("start container")
+}
+
+func (r unsupportedRuntime) StopContainer(ctx context.Context, id string, timeout *int) error {
+	return r.notSupported("stop container")
+}
+
+func (r unsupportedRuntime) RestartContainer(ctx context.Context, id string, timeout *int) error {
+	return r.notSupported("restart container")
+}
+
+func (r unsupportedRuntime) KillContainer(ctx context.Context, id string, signal string) error {
+	return r.notSupported("kill container")
+}
+
+func (r unsupportedRuntime) PauseContainer(ctx context.Context, id string) error {
+	return r.notSupported("pause container")
+}
+
+func (r unsupportedRuntime) UnpauseContainer(ctx context.Context, id string) error {
+	return r.notSupported("unpause container")
+}
+
+func (r unsupportedRuntime) RenameContainer(ctx context.Context, id string, name string) error {
+	return r.notSupported("rename container")
+}
+
+func (r unsupportedRuntime) UpdateContainer(ctx context.Context, id string, update ContainerUpdate) ([]string, error) {
+	return nil, r.notSupported("update container")
+}
+
+func (r unsupportedRuntime) WaitContainer(ctx context.Context, id string, condition string) (ContainerWaitResult, error) {
+	return ContainerWaitResult{}, r.notSupported("wait container")
+}
+
+func (r unsupportedRuntime) DeleteContainer(ctx context.Context, id string, force bool) error {
+	return r.notSupported("delete container")
+}
+
+func (r unsupportedRuntime) ContainerLogs(ctx context.Context, id string, opts LogOptions, fn func(LogLine) error) error {
+	return r.notSupported("container logs")
+}
+
+func (r unsupportedRuntime) ContainerStats(ctx context.Context, id string, stream bool, fn func(ContainerStats)) error {
+	return r.notSupported("container stats")
+}
+
+func (r unsupportedRuntime) CreateExec(ctx context.Context, containerID string, config types.ExecConfig) (types.IDResponse, error) {
+	return types.IDResponse{}, r.notSupported("exec")
+}
+
+func (r unsupportedRuntime) AttachExec(ctx context.Context, execID string, tty bool) (io.ReadWriteCloser, error) {
+	return nil, r.notSupported("exec")
+}
+
+func (r unsupportedRuntime) StartExec(ctx context.Context, execID string, config types.ExecStartCheck) error {
+	return r.notSupported("exec")
+}
+
+func (r unsupportedRuntime) ResizeExec(ctx context.Context, execID string, height, width int) error {
+	return r.notSupported("exec")
+}
+
+func (r unsupportedRuntime) ListImages(ctx context.Context, filter ListFilter) ([]ImageInfo, error) {
+	return nil, r.notSupported("list images")
+}
+
+func (r unsupportedRuntime) GetImageDetail(ctx context.Context, id string) (types.ImageInspect, error) {
+	return types.ImageInspect{}, r.notSupported("inspect image")
+}
+
+func (r unsupportedRuntime) DeleteImage(ctx context.Context, id string) error {
+	return r.notSupported("delete image")
+}
+
+func (r unsupportedRuntime) HasImage(ctx context.Context, ref string) (bool, error) {
+	return false, r.notSupported("inspect image")
+}
+
+func (r unsupportedRuntime) PullImage(ctx context.Context, ref string, opts PullOptions, progress func(PullProgress)) error {
+	return r.notSupported("pull image")
+}
+
+func (r unsupportedRuntime) ListNetworks(ctx context.Context, filter ListFilter) ([]NetworkInfo, error) {
+	return nil, r.notSupported("networks")
+}
+
+func (r unsupportedRuntime) GetNetworkDetail(ctx context.Context, id string) (types.NetworkResource, error) {
+	return types.NetworkResource{}, r.notSupported("networks")
+}
+
+func (r unsupportedRuntime) DeleteNetwork(ctx context.Context, id string) error {
+	return r.notSupported("networks")
+}
+
+func (r unsupportedRuntime) ListVolumes(ctx context.Context, filter ListFilter) ([]VolumeInfo, error) {
+	return nil, r.notSupported("volumes")
+}
+
+func (r unsupportedRuntime) GetVolumeDetail(ctx context.Context, name string) (volume.Volume, error) {
+	return volume.Volume{}, r.notSupported("volumes")
+}
+
+func (r unsupportedRuntime) DeleteVolume(ctx context.Context, name string) error {
+	return r.notSupported("volumes")
+}
+
+func (r unsupportedRuntime) Close() error { return nil }
@@ -0,0 +1,761 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	containersapi "github.com/containerd/containerd/api/services/containers/v1"
+	imagesapi "github.com/containerd/containerd/api/services/images/v1"
+	snapshotsapi "github.com/containerd/containerd/api/services/snapshots/v1"
+	tasksapi "github.com/containerd/containerd/api/services/tasks/v1"
+	versionapi "github.com/containerd/containerd/api/services/version/v1"
+	"github.com/containerd/containerd/api/types/task"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	defaultContainerdSock = "/run/containerd/containerd.sock"
+	// DefaultContainerdNamespace 未指定命名空间时使用，与 ctr 和 nerdctl 一致
+	DefaultContainerdNamespace = "default"
+	// containerdStopTimeout 停止容器时等待 SIGTERM 生效的时间，超时后发送 SIGKILL
+	containerdStopTimeout = 10 * time.Second
+	// containerd 请求命名空间的 gRPC 元数据键
+	containerdNamespaceHeader = "containerd-namespace"
+)
+
+// containerdRuntime 通过 containerd 的 gRPC API 管理已有的容器和镜像。
+// containerd 本身不提供日志、网络、数据卷和按镜像创建容器的能力（由 nerdctl、CRI 等上层实现），这些操作返回 ErrNotSupported
+type containerdRuntime struct {
+	unsupportedRuntime
+	conn      *grpc.ClientConn
+	tunnel    *sshTunnel
+	namespace string
+
+	containers containersapi.ContainersClient
+	images     imagesapi.ImagesClient
+	snapshots  snapshotsapi.SnapshotsClient
+	tasks      tasksapi.TasksClient
+	version    versionapi.VersionClient
+}
+
+// newContainerdRuntime 连接 unix socket 或经 SSH 通道连接远端的 containerd.sock
+func (s *DockerService) newContainerdRuntime(contextName string, config ContextConfig) (*containerdRuntime, error) {
+	var (
+		dialer func(ctx context.Context, addr string) (net.Conn, error)
+		tunnel *sshTunnel
+	)
+	switch {
+	case strings.HasPrefix(config.Host, "unix://"):
+		socketPath := strings.TrimPrefix(config.Host, "unix://")
+		dialer = func(ctx context.Context, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		}
+	case strings.HasPrefix(config.Host, "ssh://"):
+		config = withSSHSocket(config, defaultContainerdSock)
+		var err error
+		tunnel, err = newSSHTunnel(contextName, config.Host, config.SSH)
+		if err != nil {
+			return nil, err
+		}
+		dialer = func(ctx context.Context, addr string) (net.Conn, error) {
+			return tunnel.DialContext(ctx, "unix", addr)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported containerd host %q", config.Host)
+	}
+
+	conn, err := grpc.NewClient("passthrough:///containerd",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(dialer),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(16<<20)),
+	)
+	if err != nil {
+		if tunnel != nil {
+			tunnel.Close()
+		}
+		return nil, fmt.Errorf("failed to create containerd client: %v", err)
+	}
+
+	namespace := config.Namespace
+	if namespace == "" {
+		namespace = DefaultContainerdNamespace
+	}
+	return &containerdRuntime{
+		unsupportedRuntime: unsupportedRuntime{name: RuntimeContainerd},
+		conn:               conn,
+		tunnel:             tunnel,
+		namespace:          namespace,
+		containers:         containersapi.NewContainersClient(conn),
+		images:             imagesapi.NewImagesClient(conn),
+		snapshots:          snapshotsapi.NewSnapshotsClient(conn),
+		tasks:              tasksapi.NewTasksClient(conn),
+		version:            versionapi.NewVersionClient(conn),
+	}, nil
+}
+
+// Close 关闭 gRPC 连接和 SSH 通道
+func (r *containerdRuntime) Close() error {
+	err := r.conn.Close()
+	if r.tunnel != nil {
+		r.tunnel.Close()
+	}
+	return err
+}
+
+// withNamespace 在请求中带上 containerd 命名空间
+func (r *containerdRuntime) withNamespace(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, containerdNamespaceHeader, r.namespace)
+}
+
+func isNotFound(err error) bool {
+	return status.Code(err) == codes.NotFound
+}
+
+func (r *containerdRuntime) Ping(ctx context.Context) (types.Ping, error) {
+	version, err := r.version.Version(ctx, &emptypb.Empty{})
+	if err != nil {
+		return types.Ping{}, err
+	}
+	return types.Ping{APIVersion: version.Version, OSType: "linux"}, nil
+}
+
+func (r *containerdRuntime) Info(ctx context.Context) (types.Info, error) {
+	version, err := r.version.Version(ctx, &emptypb.Empty{})
+	if err != nil {
+		return types.Info{}, fmt.Errorf("failed to get server info: %v", err)
+	}
+	info := types.Info{
+		ServerVersion: version.Version,
+		OSType:        "linux",
+		Labels:        []string{containerdNamespaceHeader + "=" + r.namespace},
+	}
+
+	ctx = r.withNamespace(ctx)
+	containers, err := r.containers.List(ctx, &containersapi.ListContainersRequest{})
+	if err != nil {
+		return types.Info{}, fmt.Errorf("failed to get server info: %v", err)
+	}
+	processes, err := r.taskStatuses(ctx)
+	if err != nil {
+		return types.Info{}, fmt.Errorf("failed to get server info: %v", err)
+	}
+	info.Containers = len(containers.Containers)
+	for _, c := range containers.Containers {
+		switch processes[c.ID].GetStatus() {
+		case task.Status_RUNNING:
+			info.ContainersRunning++
+		case task.Status_PAUSED, task.Status_PAUSING:
+			info.ContainersPaused++
+		default:
+			info.ContainersStopped++
+		}
+	}
+	images, err := r.images.List(ctx, &imagesapi.ListImagesRequest{})
+	if err != nil {
+		return types.Info{}, fmt.Errorf("failed to get server info: %v", err)
+	}
+	info.Images = len(images.Images)
+	return info, nil
+}
+
+// taskStatuses 返回所有容器的任务，没有任务的容器不在其中
+func (r *containerdRuntime) taskStatuses(ctx context.Context) (map[string]*task.Process, error) {
+	resp, err := r.tasks.List(ctx, &tasksapi.ListTasksRequest{})
+	if err != nil {
+		return nil, err
+	}
+	processes := make(map[string]*task.Process, len(resp.Tasks))
+	for _, process := range resp.Tasks {
+		processes[process.ContainerID] = process
+	}
+	return processes, nil
+}
+
+func (r *containerdRuntime) ListContainers(ctx context.Context, filter ListFilter) ([]ContainerInfo, error) {
+	ctx = r.withNamespace(ctx)
+	resp, err := r.containers.List(ctx, &containersapi.ListContainersRequest{})
+	if err != nil {
+		return nil, err
+	}
+	processes, err := r.taskStatuses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var containerInfos []ContainerInfo
+	for _, c := range resp.Containers {
+		state, status := containerdState(processes[c.ID])
+		info := ContainerInfo{
+			ID:      shortID(c.ID),
+			Name:    containerdName(c),
+			Image:   c.Image,
+			Status:  status,
+			State:   state,
+			Created: c.CreatedAt.GetSeconds(),
+			Labels:  c.Labels,
+		}
+		// containerd 的 List 只支持自己的过滤语法，在本地筛选
+		if filter.matchContainer(info) {
+			containerInfos = append(containerInfos, info)
+		}
+	}
+	return containerInfos, nil
+}
+
+// containerdState 将任务状态转换为 Docker 的 state 和 status，没有任务的容器视为已创建
+func containerdState(process *task.Process) (string, string) {
+	switch process.GetStatus() {
+	case task.Status_RUNNING:
+		return "running", "Up"
+	case task.Status_PAUSED, task.Status_PAUSING:
+		return "paused", "Up (Paused)"
+	case task.Status_STOPPED:
+		return "exited", fmt.Sprintf("Exited (%d)", process.ExitStatus)
+	default:
+		return "created", "Created"
+	}
+}
+
+// containerdName 容器名称，依次使用 nerdctl 和 Kubernetes 的名称标签，都没有时使用 ID
+func containerdName(c *containersapi.Container) string {
+	for _, key := range []string{"nerdctl/name", "io.kubernetes.container.name"} {
+		if name := c.Labels[key]; name != "" {
+			return name
+		}
+	}
+	return c.ID
+}
+
+// shortID 与 Docker 一致，64 位十六进制的 ID 只显示前 12 位，其他 ID（如 ctr 创建的容器名）保持不变
+func shortID(id string) string {
+	if len(id) != 64 {
+		return id
+	}
+	for _, ch := range id {
+		if !strings.ContainsRune("0123456789abcdef", ch) {
+			return id
+		}
+	}
+	return id[:12]
+}
+
+// resolveContainer 按完整 ID 或唯一的 ID 前缀查找容器
+func (r *containerdRuntime) resolveContainer(ctx context.Context, id string) (*containersapi.Container, error) {
+	resp, err := r.containers.Get(ctx, &containersapi.GetContainerRequest{ID: id})
+	if err == nil {
+		return resp.Container, nil
+	}
+	if !isNotFound(err) {
+		return nil, err
+	}
+
+	list, err := r.containers.List(ctx, &containersapi.ListContainersRequest{})
+	if err != nil {
+		return nil, err
+	}
+	var found *containersapi.Container
+	for _, c := range list.Containers {
+		if !strings.HasPrefix(c.ID, id) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("multiple containers match %s", id)
+		}
+		found = c
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no such container: %s", id)
+	}
+	return found, nil
+}
+
+// getTask 返回容器的任务，没有任务时返回 nil
+func (r *containerdRuntime) getTask(ctx context.Context, containerID string) (*task.Process, error) {
+	resp, err := r.tasks.Get(ctx, &tasksapi.GetRequest{ContainerID: containerID})
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.Process, nil
+}
+
+// containerdSpec 容器 OCI 配置中展示用的部分
+type containerdSpec struct {
+	Hostname string `json:"hostname"`
+	Process  *struct {
+		Terminal bool     `json:"terminal"`
+		Args     []string `json:"args"`
+		Env      []string `json:"env"`
+		Cwd      string   `json:"cwd"`
+		User     struct {
+			UID uint32 `json:"uid"`
+			GID uint32 `json:"gid"`
+		} `json:"user"`
+	} `json:"process"`
+	Mounts []struct {
+		Destination string   `json:"destination"`
+		Type        string   `json:"type"`
+		Source      string   `json:"source"`
+		Options     []string `json:"options"`
+	} `json:"mounts"`
+}
+
+func (r *containerdRuntime) GetContainerDetail(ctx context.Context, id string) (types.ContainerJSON, error) {
+	ctx = r.withNamespace(ctx)
+	c, err := r.resolveContainer(ctx, id)
+	if err != nil {
+		return types.ContainerJSON{}, err
+	}
+	process, err := r.getTask(ctx, c.ID)
+	if err != nil {
+		return types.ContainerJSON{}, err
+	}
+
+	state, _ := containerdState(process)
+	base := &types.ContainerJSONBase{
+		ID:      c.ID,
+		Created: c.CreatedAt.AsTime().Format(time.RFC3339Nano),
+		Name:    "/" + containerdName(c),
+		Image:   c.Image,
+		Driver:  c.Snapshotter,
+		State: &types.ContainerState{
+			Status:  state,
+			Running: state == "running",
+			Paused:  state == "paused",
+		},
+		HostConfig: &container.HostConfig{},
+	}
+	if c.Runtime != nil {
+		base.Platform = c.Runtime.Name
+	}
+	if process != nil {
+		base.State.Pid = int(process.Pid)
+		base.State.ExitCode = int(process.ExitStatus)
+		if process.ExitedAt != nil {
+			base.State.FinishedAt = process.ExitedAt.AsTime().Format(time.RFC3339Nano)
+		}
+	}
+
+	config := &container.Config{
+		Image:  c.Image,
+		Labels: c.Labels,
+	}
+	var mounts []types.MountPoint
+	if c.Spec != nil {
+		var spec containerdSpec
+		if err := json.Unmarshal(c.Spec.Value, &spec); err == nil {
+			config.Hostname = spec.Hostname
+			if spec.Process != nil {
+				config.Cmd = spec.Process.Args
+				config.Env = spec.Process.Env
+				config.WorkingDir = spec.Process.Cwd
+				config.Tty = spec.Process.Terminal
+				config.User = fmt.Sprintf("%d:%d", spec.Process.User.UID, spec.Process.User.GID)
+				base.Path = firstArg(spec.Process.Args)
+			}
+			for _, m := range spec.Mounts {
+				if m.Type != "bind" {
+					continue
+				}
+				mounts = append(mounts, types.MountPoint{
+					Type:        "bind",
+					Source:      m.Source,
+					Destination: m.Destination,
+					RW:          !contains(m.Options, "ro"),
+				})
+			}
+		}
+	}
+
+	return types.ContainerJSON{
+		ContainerJSONBase: base,
+		Config:            config,
+		Mounts:            mounts,
+		NetworkSettings:   &types.NetworkSettings{},
+	}, nil
+}
+
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// StartContainer 用容器的快照创建任务并启动，已停止的旧任务会先删除
+func (r *containerdRuntime) StartContainer(ctx context.Context, id string) error {
+	ctx = r.withNamespace(ctx)
+	c, err := r.resolveContainer(ctx, id)
+	if err != nil {
+		return err
+	}
+	process, err := r.getTask(ctx, c.ID)
+	if err != nil {
+		return err
+	}
+	switch process.GetStatus() {
+	case task.Status_RUNNING:
+		return nil
+	case task.Status_CREATED:
+		_, err = r.tasks.Start(ctx, &tasksapi.StartRequest{ContainerID: c.ID})
+		return err
+	case task.Status_STOPPED:
+		if _, err := r.tasks.Delete(ctx, &tasksapi.DeleteTaskRequest{ContainerID: c.ID}); err != nil && !isNotFound(err) {
+			return err
+		}
+	case task.Status_PAUSED, task.Status_PAUSING:
+		return fmt.Errorf("container %s is paused", id)
+	}
+
+	createReq := &tasksapi.CreateTaskRequest{ContainerID: c.ID}
+	if c.SnapshotKey != "" {
+		mounts, err := r.snapshots.Mounts(ctx, &snapshotsapi.MountsRequest{Snapshotter: c.Snapshotter, Key: c.SnapshotKey})
+		if err != nil {
+			return fmt.Errorf("failed to get rootfs of container %s: %v", id, err)
+		}
+		createReq.Rootfs = mounts.Mounts
+	}
+	if _, err := r.tasks.Create(ctx, createReq); err != nil {
+		return fmt.Errorf("failed to create task: %v", err)
+	}
+	if _, err := r.tasks.Start(ctx, &tasksapi.StartRequest{ContainerID: c.ID}); err != nil {
+		r.tasks.Delete(ctx, &tasksapi.DeleteTaskRequest{ContainerID: c.ID})
+		return fmt.Errorf("failed to start task: %v", err)
+	}
+	return nil
+}
+
+// StopContainer 发送 SIGTERM，超时后发送 SIGKILL，进程退出后删除任务
+func (r *containerdRuntime) StopContainer(ctx context.Context, id string, timeout *int) error {
+	stopTimeout := containerdStopTimeout
+	if timeout != nil {
+		stopTimeout = time.Duration(*timeout) * time.Second
+	}
+	ctx = r.withNamespace(ctx)
+	c, err := r.resolveContainer(ctx, id)
+	if err != nil {
+		return err
+	}
+	process, err := r.getTask(ctx, c.ID)
+	if err != nil || process == nil {
+		return err
+	}
+	if process.Status != task.Status_STOPPED {
+		if err := r.killAndWait(ctx, c.ID, syscall.SIGTERM, stopTimeout); err != nil {
+			if err := r.killAndWait(ctx, c.ID, syscall.SIGKILL, containerdStopTimeout); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = r.tasks.Delete(ctx, &tasksapi.DeleteTaskRequest{ContainerID: c.ID})
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// killAndWait 向容器的所有进程发送信号并等待任务退出，timeout 为负数时一直等待
+func (r *containerdRuntime) killAndWait(ctx context.Context, containerID string, signal syscall.Signal, timeout time.Duration) error {
+	waitCtx, cancel := context.WithCancel(ctx)
+	if timeout >= 0 {
+		waitCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	defer cancel()
+	// 先开始等待再发送信号，避免错过退出事件
+	exited := make(chan error, 1)
+	go func() {
+		_, err := r.tasks.Wait(waitCtx, &tasksapi.WaitRequest{ContainerID: containerID})
+		exited <- err
+	}()
+	if _, err := r.tasks.Kill(ctx, &tasksapi.KillRequest{ContainerID: containerID, Signal: uint32(signal), All: true}); err != nil && !isNotFound(err) {
+		return err
+	}
+	if err := <-exited; err != nil {
+		if errors.Is(waitCtx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("container %s did not exit within %s after %s", containerID, timeout, signal)
+		}
+		return err
+	}
+	return nil
+}
+
+// RestartContainer 停止后重新创建任务
+func (r *containerdRuntime) RestartContainer(ctx context.Context, id string, timeout *int) error {
+	if err := r.StopContainer(ctx, id, timeout); err != nil {
+		return err
+	}
+	return r.StartContainer(ctx, id)
+}
+
+// containerdSignals Linux 信号名对应的编号，containerd 只接受编号
+var containerdSignals = map[string]syscall.Signal{
+	"HUP": 1, "INT": 2, "QUIT": 3, "ILL": 4, "TRAP": 5, "ABRT": 6, "BUS": 7, "FPE": 8,
+	"KILL": 9, "USR1": 10, "SEGV": 11, "USR2": 12, "PIPE": 13, "ALRM": 14, "TERM": 15,
+	"CHLD": 17, "CONT": 18, "STOP": 19, "TSTP": 20, "TTIN": 21, "TTOU": 22, "WINCH": 28,
+}
+
+// parseSignal 解析 SIGHUP、HUP 或 1 形式的信号
+func parseSignal(signal string) (syscall.Signal, error) {
+	if signal == "" {
+		return syscall.SIGKILL, nil
+	}
+	if n, err := strconv.Atoi(signal); err == nil {
+		if n <= 0 || n > 64 {
+			return 0, fmt.Errorf("invalid signal %q", signal)
+		}
+		return syscall.Signal(n), nil
+	}
+	if sig, ok := containerdSignals[strings.TrimPrefix(strings.ToUpper(signal), "SIG")]; ok {
+		return sig, nil
+	}
+	return 0, fmt.Errorf("invalid signal %q", signal)
+}
+
+// KillContainer 向容器的主进程发送信号
+func (r *containerdRuntime) KillContainer(ctx context.Context, id string, signal string) error {
+	sig, err := parseSignal(signal)
+	if err != nil {
+		return err
+	}
+	ctx = r.withNamespace(ctx)
+	c, err := r.resolveContainer(ctx, id)
+	if err != nil {
+		return err
+	}
+	_, err = r.tasks.Kill(ctx, &tasksapi.KillRequest{ContainerID: c.ID, Signal: uint32(sig)})
+	if isNotFound(err) {
+		return fmt.Errorf("container %s is not running", id)
+	}
+	return err
+}
+
+func (r *containerdRuntime) PauseContainer(ctx context.Context, id string) error {
+	ctx = r.withNamespace(ctx)
+	c, err := r.resolveContainer(ctx, id)
+	if err != nil {
+		return err
+	}
+	_, err = r.tasks.Pause(ctx, &tasksapi.PauseTaskRequest{ContainerID: c.ID})
+	if isNotFound(err) {
+		return fmt.Errorf("container %s is not running", id)
+	}
+	return err
+}
+
+func (r *containerdRuntime) UnpauseContainer(ctx context.Context, id string) error {
+	ctx = r.withNamespace(ctx)
+	c, err := r.resolveContainer(ctx, id)
+	if err != nil {
+		return err
+	}
+	_, err = r.tasks.Resume(ctx, &tasksapi.ResumeTaskRequest{ContainerID: c.ID})
+	if isNotFound(err) {
+		return fmt.Errorf("container %s is not running", id)
+	}
+	return err
+}
+
+// WaitContainer 等待任务退出。containerd 删除容器时没有可等待的事件，不支持 removed
+func (r *containerdRuntime) WaitContainer(ctx context.Context, id string, condition string) (ContainerWaitResult, error) {
+	if condition == WaitConditionRemoved {
+		return ContainerWaitResult{}, r.notSupported("wait for removal")
+	}
+	ctx = r.withNamespace(ctx)
+	c, err := r.resolveContainer(ctx, id)
+	if err != nil {
+		return ContainerWaitResult{}, err
+	}
+	process, err := r.getTask(ctx, c.ID)
+	if err != nil {
+		return ContainerWaitResult{}, err
+	}
+	if process == nil {
+		if condition == WaitConditionNextExit {
+			return ContainerWaitResult{}, fmt.Errorf("container %s is not running", id)
+		}
+		return ContainerWaitResult{}, nil
+	}
+	if process.Status == task.Status_STOPPED && condition == WaitConditionNotRunning {
+		return ContainerWaitResult{StatusCode: int64(process.ExitStatus)}, nil
+	}
+	resp, err := r.tasks.Wait(ctx, &tasksapi.WaitRequest{ContainerID: c.ID})
+	if err != nil {
+		return ContainerWaitResult{}, err
+	}
+	return ContainerWaitResult{StatusCode: int64(resp.ExitStatus)}, nil
+}
+
+// DeleteContainer 删除容器及其快照，运行中的容器需要 force
+func (r *containerdRuntime) DeleteContainer(ctx context.Context, id string, force bool) error {
+	ctx = r.withNamespace(ctx)
+	c, err := r.resolveContainer(ctx, id)
+	if err != nil {
+		return err
+	}
+	process, err := r.getTask(ctx, c.ID)
+	if err != nil {
+		return err
+	}
+	if process != nil {
+		if process.Status != task.Status_STOPPED && process.Status != task.Status_CREATED {
+			if !force {
+				return fmt.Errorf("cannot remove running container %s, stop it first or use force", id)
+			}
+			if err := r.killAndWait(ctx, c.ID, syscall.SIGKILL, containerdStopTimeout); err != nil {
+				return err
+			}
+		}
+		if _, err := r.tasks.Delete(ctx, &tasksapi.DeleteTaskRequest{ContainerID: c.ID}); err != nil && !isNotFound(err) {
+			return err
+		}
+	}
+
+	if _, err := r.containers.Delete(ctx, &containersapi.DeleteContainerRequest{ID: c.ID}); err != nil {
+		return err
+	}
+	if c.SnapshotKey != "" {
+		_, err := r.snapshots.Remove(ctx, &snapshotsapi.RemoveSnapshotRequest{Snapshotter: c.Snapshotter, Key: c.SnapshotKey})
+		if err != nil && !isNotFound(err) {
+			return fmt.Errorf("container deleted but failed to remove its snapshot: %v", err)
+		}
+	}
+	return nil
+}
+
+func (r *containerdRuntime) ListImages(ctx context.Context, filter ListFilter) ([]ImageInfo, error) {
+	resp, err := r.images.List(r.withNamespace(ctx), &imagesapi.ListImagesRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	var imageInfos []ImageInfo
+	for _, image := range resp.Images {
+		repository, tag := splitImageName(image.Name)
+		info := ImageInfo{
+			ID:         imageShortID(image.Target.GetDigest()),
+			Repository: repository,
+			Tag:        tag,
+			// containerd 只记录清单的大小，镜像层的大小需要遍历内容存储
+			Size:    image.Target.GetSize(),
+			Created: image.CreatedAt.GetSeconds(),
+			Labels:  image.Labels,
+		}
+		if filter.matchImage(info) {
+			imageInfos = append(imageInfos, info)
+		}
+	}
+	return imageInfos, nil
+}
+
+// splitImageName 拆分 name:tag，镜像名可能带有 registry 端口或 digest
+func splitImageName(name string) (string, string) {
+	if i := strings.LastIndex(name, "@"); i >= 0 {
+		return name[:i], "<none>"
+	}
+	i := strings.LastIndex(name, ":")
+	if i < 0 || strings.Contains(name[i:], "/") {
+		return name, "latest"
+	}
+	return name[:i], name[i+1:]
+}
+
+// imageShortID 与 Docker 的镜像列表一致，移除 sha256: 前缀并截取前 12 位
+func imageShortID(digest string) string {
+	id := strings.TrimPrefix(digest, "sha256:")
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// resolveImages 按镜像名或 digest 前缀查找镜像，返回指向同一内容的所有镜像名
+func (r *containerdRuntime) resolveImages(ctx context.Context, id string) ([]*imagesapi.Image, error) {
+	resp, err := r.images.List(ctx, &imagesapi.ListImagesRequest{})
+	if err != nil {
+		return nil, err
+	}
+	digest := ""
+	for _, image := range resp.Images {
+		if image.Name == id {
+			digest = image.Target.GetDigest()
+			break
+		}
+	}
+	prefix := strings.TrimPrefix(id, "sha256:")
+	var found []*imagesapi.Image
+	for _, image := range resp.Images {
+		imageDigest := image.Target.GetDigest()
+		if digest != "" && imageDigest == digest || digest == "" && strings.HasPrefix(strings.TrimPrefix(imageDigest, "sha256:"), prefix) {
+			found = append(found, image)
+		}
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no such image: %s", id)
+	}
+	return found, nil
+}
+
+func (r *containerdRuntime) GetImageDetail(ctx context.Context, id string) (types.ImageInspect, error) {
+	images, err := r.resolveImages(r.withNamespace(ctx), id)
+	if err != nil {
+		return types.ImageInspect{}, err
+	}
+	image := images[0]
+	inspect := types.ImageInspect{
+		ID:      image.Target.GetDigest(),
+		Created: image.CreatedAt.AsTime().Format(time.RFC3339Nano),
+		Size:    image.Target.GetSize(),
+		Os:      "linux",
+		Config:  &container.Config{Labels: image.Labels},
+	}
+	for _, i := range images {
+		inspect.RepoTags = append(inspect.RepoTags, i.Name)
+	}
+	sort.Strings(inspect.RepoTags)
+	return inspect, nil
+}
+
+// DeleteImage 删除镜像名，按 ID 删除时要求该 ID 只对应一个镜像名，与 Docker 的行为一致
+func (r *containerdRuntime) DeleteImage(ctx context.Context, id string) error {
+	ctx = r.withNamespace(ctx)
+	images, err := r.resolveImages(ctx, id)
+	if err != nil {
+		return err
+	}
+	name := id
+	if len(images) > 1 && !containsImageName(images, id) {
+		return fmt.Errorf("image %s is referenced in multiple repositories, delete it by name", id)
+	}
+	if len(images) == 1 {
+		name = images[0].Name
+	}
+	_, err = r.images.Delete(ctx, &imagesapi.DeleteImageRequest{Name: name})
+	return err
+}
+
+func containsImageName(images []*imagesapi.Image, name string) bool {
+	for _, image := range images {
+		if image.Name == name {
+			return true
+		}
+	}
+	return false
+}
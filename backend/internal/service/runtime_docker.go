@@ -0,0 +1,699 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// dockerRuntime 通过 Docker Engine API 操作容器，ssh 类型的 context 同时持有其 SSH 通道
+type dockerRuntime struct {
+	cli    *client.Client
+	tunnel *sshTunnel
+}
+
+// newDockerRuntime 按 context 配置创建 Docker 运行时
+func (s *DockerService) newDockerRuntime(contextName string, config ContextConfig) (*dockerRuntime, error) {
+	cli, tunnel, err := s.newDockerClient(contextName, config)
+	if err != nil {
+		return nil, err
+	}
+	return &dockerRuntime{cli: cli, tunnel: tunnel}, nil
+}
+
+func (r *dockerRuntime) Name() string {
+	return RuntimeDocker
+}
+
+// Close 关闭 client 和 SSH 通道
+func (r *dockerRuntime) Close() error {
+	err := r.cli.Close()
+	if r.tunnel != nil {
+		r.tunnel.Close()
+	}
+	return err
+}
+
+func (r *dockerRuntime) Ping(ctx context.Context) (types.Ping, error) {
+	return r.cli.Ping(ctx)
+}
+
+func (r *dockerRuntime) ListContainers(ctx context.Context, filter ListFilter) (containerInfos []ContainerInfo, err error) {
+	containers, err := r.cli.ContainerList(ctx, types.ContainerListOptions{All: true, Filters: filter.dockerArgs(ResourceContainer)})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, container := range containers {
+		// 处理容器名称，移除开头的 "/"
+		name := strings.TrimPrefix(container.Names[0], "/")
+
+		// 转换端口信息
+		var ports []Port
+		for _, p := range container.Ports {
+			ports = append(ports, Port{
+				IP:          p.IP,
+				PrivatePort: p.PrivatePort,
+				PublicPort:  p.PublicPort,
+				Type:        p.Type,
+			})
+		}
+
+		containerInfos = append(containerInfos, ContainerInfo{
+			ID:      container.ID[:12], // 只显示ID的前12位
+			Name:    name,
+			Image:   container.Image,
+			Status:  container.Status,
+			State:   container.State,
+			Created: container.Created,
+			Ports:   ports,
+			Labels:  container.Labels,
+		})
+	}
+
+	return containerInfos, nil
+}
+
+func (r *dockerRuntime) StartContainer(ctx context.Context, id string) (err error) {
+	return r.cli.ContainerStart(ctx, id, types.ContainerStartOptions{})
+}
+
+func (r *dockerRuntime) StopContainer(ctx context.Context, id string, timeout *int) (err error) {
+	return r.cli.ContainerStop(ctx, id, container.StopOptions{Timeout: timeout})
+}
+
+func (r *dockerRuntime) RestartContainer(ctx context.Context, id string, timeout *int) error {
+	return r.cli.ContainerRestart(ctx, id, container.StopOptions{Timeout: timeout})
+}
+
+// KillContainer 信号可以是 SIGHUP、HUP 或 1 等形式，由 daemon 解析
+func (r *dockerRuntime) KillContainer(ctx context.Context, id string, signal string) error {
+	return r.cli.ContainerKill(ctx, id, signal)
+}
+
+func (r *dockerRuntime) PauseContainer(ctx context.Context, id string) error {
+	return r.cli.ContainerPause(ctx, id)
+}
+
+func (r *dockerRuntime) UnpauseContainer(ctx context.Context, id string) error {
+	return r.cli.ContainerUnpause(ctx, id)
+}
+
+func (r *dockerRuntime) RenameContainer(ctx context.Context, id string, name string) error {
+	return r.cli.ContainerRename(ctx, id, name)
+}
+
+// UpdateContainer 原地修改资源限制和重启策略，返回 daemon 的警告
+func (r *dockerRuntime) UpdateContainer(ctx context.Context, id string, update ContainerUpdate) ([]string, error) {
+	config := container.UpdateConfig{
+		Resources: container.Resources{
+			NanoCPUs:          int64(update.Resources.CPUs * 1e9),
+			CPUShares:         update.Resources.CPUShares,
+			Memory:            update.Resources.Memory,
+			MemoryReservation: update.Resources.MemoryReservation,
+			MemorySwap:        update.Resources.MemorySwap,
+		},
+	}
+	if update.Resources.PidsLimit > 0 {
+		pidsLimit := update.Resources.PidsLimit
+		config.PidsLimit = &pidsLimit
+	}
+	if update.RestartPolicy != "" {
+		config.RestartPolicy = container.RestartPolicy{Name: update.RestartPolicy, MaximumRetryCount: update.MaxRetries}
+	}
+	resp, err := r.cli.ContainerUpdate(ctx, id, config)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Warnings == nil {
+		return []string{}, nil
+	}
+	return resp.Warnings, nil
+}
+
+func (r *dockerRuntime) WaitContainer(ctx context.Context, id string, condition string) (ContainerWaitResult, error) {
+	resultCh, errCh := r.cli.ContainerWait(ctx, id, container.WaitCondition(condition))
+	select {
+	case resp := <-resultCh:
+		result := ContainerWaitResult{StatusCode: resp.StatusCode}
+		if resp.Error != nil {
+			result.Error = resp.Error.Message
+		}
+		return result, nil
+	case err := <-errCh:
+		return ContainerWaitResult{}, err
+	}
+}
+
+func (r *dockerRuntime) GetContainerDetail(ctx context.Context, id string) (detail types.ContainerJSON, err error) {
+	return r.cli.ContainerInspect(ctx, id)
+}
+
+func (r *dockerRuntime) ListImages(ctx context.Context, filter ListFilter) (imageInfos []ImageInfo, err error) {
+	images, err := r.cli.ImageList(ctx, types.ImageListOptions{All: true, Filters: filter.dockerArgs(ResourceImage)})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, image := range images {
+		// 处理 RepoTags，可能为空
+		repository := "<none>"
+		tag := "<none>"
+		if len(image.RepoTags) > 0 {
+			parts := strings.Split(image.RepoTags[0], ":")
+			if len(parts) == 2 {
+				repository = parts[0]
+				tag = parts[1]
+			}
+		}
+
+		imageInfos = append(imageInfos, ImageInfo{
+			ID:         image.ID[7:19], // 移除 "sha256:" 前缀并截取
+			Repository: repository,
+			Tag:        tag,
+			Size:       image.Size,
+			Created:    image.Created,
+			Labels:     image.Labels,
+		})
+	}
+
+	return imageInfos, nil
+}
+
+func (r *dockerRuntime) DeleteImage(ctx context.Context, id string) (err error) {
+	_, err = r.cli.ImageRemove(ctx, id, types.ImageRemoveOptions{Force: false})
+	return err
+}
+
+func (r *dockerRuntime) CreateContainer(ctx context.Context, config ContainerConfig) (CreateContainerResult, error) {
+	containerConfig, hostConfig, networkingConfig, err := dockerCreateConfig(config)
+	if err != nil {
+		return CreateContainerResult{}, err
+	}
+	var platform *ocispec.Platform
+	if config.Platform != "" {
+		if platform, err = parsePlatform(config.Platform); err != nil {
+			return CreateContainerResult{}, err
+		}
+	}
+
+	// 创建容器
+	resp, err := r.cli.ContainerCreate(
+		ctx,
+		containerConfig,
+		hostConfig,
+		networkingConfig,
+		platform,
+		config.Name, // 如果名称为空，Docker 会自动生成
+	)
+	if err != nil {
+		return CreateContainerResult{}, fmt.Errorf("failed to create container: %v", err)
+	}
+	result := CreateContainerResult{ID: resp.ID, Warnings: resp.Warnings}
+	if result.Warnings == nil {
+		result.Warnings = []string{}
+	}
+
+	// API 1.44 之前创建时只能连接一个网络，其余网络在启动前连接
+	for _, n := range config.Networks {
+		if container.NetworkMode(n.Name) == hostConfig.NetworkMode {
+			continue
+		}
+		if err := r.cli.NetworkConnect(ctx, n.Name, resp.ID, dockerEndpointSettings(n)); err != nil {
+			return r.rollbackCreate(ctx, result, fmt.Errorf("failed to connect container to network %s: %v", n.Name, err))
+		}
+	}
+
+	if !config.Start {
+		return result, nil
+	}
+	// 启动容器
+	if err := r.cli.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
+		return r.rollbackCreate(ctx, result, fmt.Errorf("failed to start container: %v", err))
+	}
+	result.Started = true
+	return result, nil
+}
+
+// rollbackCreate 删除配置网络或启动失败的容器及其匿名数据卷。
+// 请求可能已被取消，删除使用独立的超时；删除失败时返回残留容器的 ID
+func (r *dockerRuntime) rollbackCreate(ctx context.Context, result CreateContainerResult, cause error) (CreateContainerResult, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+	err := r.cli.ContainerRemove(ctx, result.ID, types.ContainerRemoveOptions{Force: true, RemoveVolumes: true})
+	if err != nil {
+		return result, fmt.Errorf("%v; container %s was left behind: %v", cause, shortID(result.ID), err)
+	}
+	return CreateContainerResult{}, fmt.Errorf("%v; container removed", cause)
+}
+
+// dockerCreateConfig 将容器配置转换为 Docker API 的创建参数
+func dockerCreateConfig(config ContainerConfig) (*container.Config, *container.HostConfig, *network.NetworkingConfig, error) {
+	// 准备端口绑定
+	portBindings := nat.PortMap{}
+	exposedPorts := nat.PortSet{}
+	for _, p := range config.Ports {
+		protocol := p.Protocol
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		hostIP := p.HostIP
+		if hostIP == "" {
+			hostIP = "0.0.0.0"
+		}
+		containerPort := nat.Port(fmt.Sprintf("%d/%s", p.Container, protocol))
+		exposedPorts[containerPort] = struct{}{}
+		// 未指定主机端口时只暴露端口，不做映射
+		if p.Host == 0 {
+			continue
+		}
+		// 同一容器端口可以映射到多个主机端口
+		portBindings[containerPort] = append(portBindings[containerPort], nat.PortBinding{
+			HostIP:   hostIP,
+			HostPort: fmt.Sprintf("%d", p.Host),
+		})
+	}
+
+	// 准备环境变量
+	var env []string
+	if len(config.Env) > 0 {
+		env = make([]string, len(config.Env))
+		for i, e := range config.Env {
+			env[i] = fmt.Sprintf("%s=%s", e.Key, e.Value)
+		}
+	}
+
+	// 准备数据卷，bind 沿用 Binds，数据卷和 tmpfs 使用 Mounts
+	var (
+		binds  []string
+		mounts []mount.Mount
+	)
+	for _, v := range config.Volumes {
+		switch v.Type {
+		case VolumeTypeVolume:
+			mounts = append(mounts, mount.Mount{
+				Type:     mount.TypeVolume,
+				Source:   v.Host,
+				Target:   v.Container,
+				ReadOnly: v.Mode == "ro",
+			})
+		case VolumeTypeTmpfs:
+			m := mount.Mount{
+				Type:     mount.TypeTmpfs,
+				Target:   v.Container,
+				ReadOnly: v.Mode == "ro",
+			}
+			if v.TmpfsSize > 0 {
+				m.TmpfsOptions = &mount.TmpfsOptions{SizeBytes: v.TmpfsSize}
+			}
+			mounts = append(mounts, m)
+		default:
+			bind := fmt.Sprintf("%s:%s", v.Host, v.Container)
+			if v.Mode != "" {
+				bind += ":" + v.Mode
+			}
+			binds = append(binds, bind)
+		}
+	}
+
+	// 准备重启策略
+	var restartPolicy container.RestartPolicy
+	switch config.RestartPolicy {
+	case "always":
+		restartPolicy = container.RestartPolicy{Name: "always"}
+	case "unless-stopped":
+		restartPolicy = container.RestartPolicy{Name: "unless-stopped"}
+	case "on-failure":
+		restartPolicy = container.RestartPolicy{Name: "on-failure", MaximumRetryCount: config.MaxRetries}
+	default:
+		restartPolicy = container.RestartPolicy{Name: "no"}
+	}
+
+	// 准备命令和参数
+	var cmd []string
+	if config.Command != "" {
+		cmd = append(cmd, config.Command)
+		if len(config.Args) > 0 {
+			cmd = append(cmd, config.Args...)
+		}
+	}
+
+	// 创建容器配置
+	containerConfig := &container.Config{
+		Image:      config.ImageID,
+		WorkingDir: config.WorkingDir,
+		User:       config.User,
+		Labels:     config.Labels,
+	}
+
+	// 只有在有命令时才设置
+	if len(cmd) > 0 {
+		containerConfig.Cmd = cmd
+	}
+	if len(config.Entrypoint) > 0 {
+		containerConfig.Entrypoint = config.Entrypoint
+	}
+
+	// 只有在有端口时才设置
+	if len(exposedPorts) > 0 {
+		containerConfig.ExposedPorts = exposedPorts
+	}
+
+	// 只有在有环境变量时才设置
+	if len(env) > 0 {
+		containerConfig.Env = env
+	}
+
+	if h := config.Healthcheck; h != nil {
+		containerConfig.Healthcheck = &container.HealthConfig{
+			Test:        h.Test,
+			Interval:    h.Interval,
+			Timeout:     h.Timeout,
+			StartPeriod: h.StartPeriod,
+			Retries:     h.Retries,
+		}
+	}
+
+	// 主机配置
+	hostConfig := &container.HostConfig{
+		RestartPolicy: restartPolicy,
+		Mounts:        mounts,
+		CapAdd:        config.CapAdd,
+		CapDrop:       config.CapDrop,
+	}
+
+	// 只有在有端口映射时才设置
+	if len(portBindings) > 0 {
+		hostConfig.PortBindings = portBindings
+	}
+
+	// 只有在有数据卷时才设置
+	if len(binds) > 0 {
+		hostConfig.Binds = binds
+	}
+
+	// 资源限制
+	resources := config.Resources
+	hostConfig.NanoCPUs = int64(resources.CPUs * 1e9)
+	hostConfig.CPUShares = resources.CPUShares
+	hostConfig.Memory = resources.Memory
+	hostConfig.MemoryReservation = resources.MemoryReservation
+	hostConfig.MemorySwap = resources.MemorySwap
+	if resources.PidsLimit > 0 {
+		pidsLimit := resources.PidsLimit
+		hostConfig.PidsLimit = &pidsLimit
+	}
+	for _, d := range config.Devices {
+		target := d.Container
+		if target == "" {
+			target = d.Host
+		}
+		permissions := d.Permissions
+		if permissions == "" {
+			permissions = "rwm"
+		}
+		hostConfig.Devices = append(hostConfig.Devices, container.DeviceMapping{
+			PathOnHost:        d.Host,
+			PathInContainer:   target,
+			CgroupPermissions: permissions,
+		})
+	}
+	for _, u := range config.Ulimits {
+		hostConfig.Ulimits = append(hostConfig.Ulimits, &units.Ulimit{Name: u.Name, Soft: u.Soft, Hard: u.Hard})
+	}
+
+	if config.LogDriver != "" {
+		hostConfig.LogConfig = container.LogConfig{Type: config.LogDriver, Config: config.LogOptions}
+	} else if len(config.LogOptions) > 0 {
+		return nil, nil, nil, fmt.Errorf("log options require a log driver")
+	}
+
+	// 只有在指定网络模式时才设置，指定了网络时默认使用第一个网络
+	networkMode := config.NetworkMode
+	if networkMode == "" && len(config.Networks) > 0 {
+		networkMode = config.Networks[0].Name
+	}
+	if networkMode != "" {
+		hostConfig.NetworkMode = container.NetworkMode(networkMode)
+	}
+
+	// 创建时只连接网络模式对应的网络，其他网络由 CreateContainer 在创建后连接
+	var networkingConfig *network.NetworkingConfig
+	for _, n := range config.Networks {
+		if n.Name == networkMode {
+			networkingConfig = &network.NetworkingConfig{
+				EndpointsConfig: map[string]*network.EndpointSettings{
+					n.Name: dockerEndpointSettings(n),
+				},
+			}
+		}
+	}
+	return containerConfig, hostConfig, networkingConfig, nil
+}
+
+// dockerEndpointSettings 网络别名和静态 IP
+func dockerEndpointSettings(n NetworkAttachment) *network.EndpointSettings {
+	settings := &network.EndpointSettings{Aliases: n.Aliases}
+	if n.IPv4Address != "" || n.IPv6Address != "" {
+		settings.IPAMConfig = &network.EndpointIPAMConfig{
+			IPv4Address: n.IPv4Address,
+			IPv6Address: n.IPv6Address,
+		}
+	}
+	return settings
+}
+
+func (r *dockerRuntime) GetImageDetail(ctx context.Context, id string) (inspect types.ImageInspect, err error) {
+	inspect, _, err = r.cli.ImageInspectWithRaw(ctx, id)
+	if err != nil {
+		return types.ImageInspect{}, err
+	}
+	return inspect, nil
+}
+
+func (r *dockerRuntime) HasImage(ctx context.Context, ref string) (bool, error) {
+	_, _, err := r.cli.ImageInspectWithRaw(ctx, ref)
+	if client.IsErrNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// PullImage 拉取镜像并逐条解析 daemon 返回的进度，拉取失败的错误包含在进度消息中
+func (r *dockerRuntime) PullImage(ctx context.Context, ref string, opts PullOptions, progress func(PullProgress)) error {
+	pullOptions := types.ImagePullOptions{Platform: opts.Platform}
+	if opts.Auth != nil {
+		encoded, err := registry.EncodeAuthConfig(registry.AuthConfig{
+			Username:      opts.Auth.Username,
+			Password:      opts.Auth.Password,
+			IdentityToken: opts.Auth.IdentityToken,
+			ServerAddress: opts.Auth.ServerAddress,
+		})
+		if err != nil {
+			return err
+		}
+		pullOptions.RegistryAuth = encoded
+	}
+
+	reader, err := r.cli.ImagePull(ctx, ref, pullOptions)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	decoder := json.NewDecoder(reader)
+	for {
+		var msg struct {
+			ID             string `json:"id"`
+			Status         string `json:"status"`
+			Progress       string `json:"progress"`
+			ProgressDetail struct {
+				Current int64 `json:"current"`
+				Total   int64 `json:"total"`
+			} `json:"progressDetail"`
+			Error string `json:"error"`
+		}
+		if err := decoder.Decode(&msg); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if msg.Error != "" {
+			return errors.New(msg.Error)
+		}
+		progress(PullProgress{
+			ID:       msg.ID,
+			Status:   msg.Status,
+			Progress: msg.Progress,
+			Current:  msg.ProgressDetail.Current,
+			Total:    msg.ProgressDetail.Total,
+		})
+	}
+}
+
+func (r *dockerRuntime) ListNetworks(ctx context.Context, filter ListFilter) (networkInfos []NetworkInfo, err error) {
+	networks, err := r.cli.NetworkList(ctx, types.NetworkListOptions{Filters: filter.dockerArgs(ResourceNetwork)})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, network := range networks {
+		networkInfos = append(networkInfos, NetworkInfo{
+			ID:      network.ID,
+			Name:    network.Name,
+			Driver:  network.Driver,
+			Scope:   network.Scope,
+			IPAM:    network.IPAM,
+			Created: network.Created,
+		})
+	}
+
+	return networkInfos, nil
+}
+
+func (r *dockerRuntime) GetNetworkDetail(ctx context.Context, id string) (detail types.NetworkResource, err error) {
+	return r.cli.NetworkInspect(ctx, id, types.NetworkInspectOptions{})
+}
+
+func (r *dockerRuntime) DeleteNetwork(ctx context.Context, id string) (err error) {
+	return r.cli.NetworkRemove(ctx, id)
+}
+
+func (r *dockerRuntime) ListVolumes(ctx context.Context, filter ListFilter) (volumeInfos []VolumeInfo, err error) {
+	volumes, err := r.cli.VolumeList(ctx, volume.ListOptions{Filters: filter.dockerArgs(ResourceVolume)})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, volume := range volumes.Volumes {
+		volumeInfos = append(volumeInfos, VolumeInfo{
+			Name:       volume.Name,
+			Driver:     volume.Driver,
+			Mountpoint: volume.Mountpoint,
+			CreatedAt:  volume.CreatedAt,
+			Labels:     volume.Labels,
+			Scope:      volume.Scope,
+			Options:    volume.Options,
+		})
+	}
+
+	return volumeInfos, nil
+}
+
+func (r *dockerRuntime) GetVolumeDetail(ctx context.Context, name string) (detail volume.Volume, err error) {
+	return r.cli.VolumeInspect(ctx, name)
+}
+
+func (r *dockerRuntime) DeleteVolume(ctx context.Context, name string) (err error) {
+	return r.cli.VolumeRemove(ctx, name, true)
+}
+
+// ContainerLogs 总是请求时间戳，由调用方决定是否返回
+func (r *dockerRuntime) ContainerLogs(ctx context.Context, id string, opts LogOptions, fn func(LogLine) error) error {
+	// TTY 容器的日志没有帧头，需要按不同方式读取
+	inspect, err := r.cli.ContainerInspect(ctx, id)
+	if err != nil {
+		return err
+	}
+	logs, err := r.cli.ContainerLogs(ctx, id, types.ContainerLogsOptions{
+		ShowStdout: opts.Stdout,
+		ShowStderr: opts.Stderr,
+		Since:      opts.Since,
+		Until:      opts.Until,
+		Timestamps: true,
+		Follow:     opts.Follow,
+		Tail:       opts.tail(),
+	})
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+	return readDockerLogs(logs, inspect.Config != nil && inspect.Config.Tty, fn)
+}
+
+func (r *dockerRuntime) ContainerStats(ctx context.Context, id string, stream bool, fn func(ContainerStats)) error {
+	resp, err := r.cli.ContainerStats(ctx, id, stream)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var raw types.StatsJSON
+		if err := decoder.Decode(&raw); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		fn(convertDockerStats(raw))
+	}
+}
+
+func (r *dockerRuntime) DeleteContainer(ctx context.Context, id string, force bool) (err error) {
+	options := types.ContainerRemoveOptions{
+		Force:         force, // 如果容器正在运行，是否强制删除
+		RemoveVolumes: false, // 默认不删除关联的匿名卷
+	}
+	return r.cli.ContainerRemove(ctx, id, options)
+}
+
+// CreateExec 创建执行实例
+func (r *dockerRuntime) CreateExec(ctx context.Context, containerID string, config types.ExecConfig) (resp types.IDResponse, err error) {
+	return r.cli.ContainerExecCreate(ctx, containerID, config)
+}
+
+// AttachExec 附加到执行实例
+func (r *dockerRuntime) AttachExec(ctx context.Context, execID string, tty bool) (conn io.ReadWriteCloser, err error) {
+	resp, err := r.cli.ContainerExecAttach(ctx, execID, types.ExecStartCheck{
+		Tty:    tty,
+		Detach: false,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach exec: %v", err)
+	}
+	return resp.Conn, nil
+}
+
+// StartExec 启动执行实例
+func (r *dockerRuntime) StartExec(ctx context.Context, execID string, config types.ExecStartCheck) (err error) {
+	err = r.cli.ContainerExecStart(ctx, execID, config)
+	if err != nil {
+		return fmt.Errorf("failed to start exec: %v", err)
+	}
+	return nil
+}
+
+// ResizeExec 调整终端大小
+func (r *dockerRuntime) ResizeExec(ctx context.Context, execID string, height, width int) (err error) {
+	return r.cli.ContainerExecResize(ctx, execID, types.ResizeOptions{
+		Height: uint(height),
+		Width:  uint(width),
+	})
+}
+
+// GetServerInfo 获取服务器信息
+func (r *dockerRuntime) Info(ctx context.Context) (info types.Info, err error) {
+	info, err = r.cli.Info(ctx)
+	if err != nil {
+		return types.Info{}, fmt.Errorf("failed to get server info: %v", err)
+	}
+
+	return info, nil
+}
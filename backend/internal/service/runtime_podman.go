@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// 远端 rootful Podman 服务的默认 socket，rootless 为 /run/user/<uid>/podman/podman.sock
+	defaultPodmanSock = "/run/podman/podman.sock"
+	// libpod API 的路径前缀，Podman 4 及以上版本均支持
+	libpodAPIPrefix = "/v4.0.0/libpod"
+)
+
+// PodInfo Podman pod 的概要信息
+type PodInfo struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Status     string            `json:"status"`
+	Created    time.Time         `json:"created"`
+	InfraID    string            `json:"infraId,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Containers []PodContainer    `json:"containers"`
+}
+
+// PodContainer pod 中的容器
+type PodContainer struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// podmanRuntime 容器、镜像、网络和数据卷通过 Podman 兼容 Docker 的 API 操作，pod 通过 libpod API 操作
+type podmanRuntime struct {
+	*dockerRuntime
+	httpClient *http.Client
+	// baseURL libpod 请求的地址，unix socket 和 SSH 通道由 httpClient 的拨号函数决定实际连接
+	baseURL string
+}
+
+// newPodmanRuntime 按 context 配置创建 Podman 运行时，连接方式与 Docker 相同
+func (s *DockerService) newPodmanRuntime(contextName string, config ContextConfig) (*podmanRuntime, error) {
+	docker, err := s.newDockerRuntime(contextName, withSSHSocket(config, defaultPodmanSock))
+	if err != nil {
+		return nil, err
+	}
+	baseURL, err := libpodBaseURL(docker.cli.DaemonHost(), config.TLS != nil)
+	if err != nil {
+		docker.Close()
+		return nil, err
+	}
+	return &podmanRuntime{
+		dockerRuntime: docker,
+		httpClient:    docker.cli.HTTPClient(),
+		baseURL:       baseURL,
+	}, nil
+}
+
+// libpodBaseURL 根据 Docker client 的 daemon 地址生成 libpod 请求的地址
+func libpodBaseURL(daemonHost string, useTLS bool) (string, error) {
+	u, err := url.Parse(daemonHost)
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "unix", "npipe":
+		return "http://podman", nil
+	case "tcp":
+		if useTLS {
+			return "https://" + u.Host, nil
+		}
+		return "http://" + u.Host, nil
+	case "http", "https":
+		return u.Scheme + "://" + u.Host, nil
+	default:
+		return "", fmt.Errorf("unsupported podman host %q", daemonHost)
+	}
+}
+
+func (r *podmanRuntime) Name() string {
+	return RuntimePodman
+}
+
+// libpod 调用 libpod API，out 不为空时解析响应。304 表示 pod 已处于目标状态，不视为错误
+func (r *podmanRuntime) libpod(ctx context.Context, method, path string, query url.Values, out any) error {
+	u := r.baseURL + libpodAPIPrefix + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("podman %s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("podman: %s", apiErr.Message)
+		}
+		return fmt.Errorf("podman %s %s: %s", method, path, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// ListPods 列出所有 pod
+func (r *podmanRuntime) ListPods(ctx context.Context) ([]PodInfo, error) {
+	var reports []struct {
+		ID         string            `json:"Id"`
+		Name       string            `json:"Name"`
+		Status     string            `json:"Status"`
+		Created    time.Time         `json:"Created"`
+		InfraID    string            `json:"InfraId"`
+		Labels     map[string]string `json:"Labels"`
+		Containers []struct {
+			ID     string `json:"Id"`
+			Names  string `json:"Names"`
+			Status string `json:"Status"`
+		} `json:"Containers"`
+	}
+	if err := r.libpod(ctx, http.MethodGet, "/pods/json", nil, &reports); err != nil {
+		return nil, err
+	}
+
+	pods := make([]PodInfo, 0, len(reports))
+	for _, report := range reports {
+		pod := PodInfo{
+			ID:         report.ID,
+			Name:       report.Name,
+			Status:     report.Status,
+			Created:    report.Created,
+			InfraID:    report.InfraID,
+			Labels:     report.Labels,
+			Containers: make([]PodContainer, 0, len(report.Containers)),
+		}
+		for _, c := range report.Containers {
+			pod.Containers = append(pod.Containers, PodContainer{
+				ID:     c.ID,
+				Name:   strings.TrimPrefix(c.Names, "/"),
+				Status: c.Status,
+			})
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+// GetPodDetail 返回 podman pod inspect 的原始结果
+func (r *podmanRuntime) GetPodDetail(ctx context.Context, id string) (map[string]any, error) {
+	var detail map[string]any
+	err := r.libpod(ctx, http.MethodGet, "/pods/"+url.PathEscape(id)+"/json", nil, &detail)
+	return detail, err
+}
+
+// StartPod 启动 pod 中的所有容器
+func (r *podmanRuntime) StartPod(ctx context.Context, id string) error {
+	return r.libpod(ctx, http.MethodPost, "/pods/"+url.PathEscape(id)+"/start", nil, nil)
+}
+
+// StopPod 停止 pod 中的所有容器
+func (r *podmanRuntime) StopPod(ctx context.Context, id string) error {
+	return r.libpod(ctx, http.MethodPost, "/pods/"+url.PathEscape(id)+"/stop", nil, nil)
+}
+
+// DeletePod 删除 pod，force 为 true 时先停止运行中的容器
+func (r *podmanRuntime) DeletePod(ctx context.Context, id string, force bool) error {
+	query := url.Values{}
+	if force {
+		query.Set("force", "true")
+	}
+	return r.libpod(ctx, http.MethodDelete, "/pods/"+url.PathEscape(id), query, nil)
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	defaultDockerSock = "/var/run/docker.sock"
+	defaultSSHPort    = "22"
+	sshDialTimeout    = 10 * time.Second
+)
+
+// SSHConfig ssh:// 类型 context 的连接配置，对应 docker -H ssh://user@host
+type SSHConfig struct {
+	// PrivateKey 为 PEM 格式的私钥，仅在创建或更新时提交，与 context 配置一起保存在存储后端中，不会在接口中返回。
+	// 更新时留空表示保留已保存的私钥；未保存私钥时使用 SSH_AUTH_SOCK 指向的 ssh-agent
+	PrivateKey string `json:"privateKey,omitempty"`
+	// KnownHosts known_hosts 文件路径，默认为 ~/.ssh/known_hosts，主机密钥不匹配时拒绝连接
+	KnownHosts string `json:"knownHosts,omitempty"`
+	// JumpHosts 按顺序经过的跳板机，格式为 [ssh://]user@host[:port]，使用相同的认证方式
+	JumpHosts []string `json:"jumpHosts,omitempty"`
+	// SocketPath 远端 dockerd 的 unix socket 路径，默认为 /var/run/docker.sock
+	SocketPath string `json:"socketPath,omitempty"`
+}
+
+// sshEndpoint SSH 连接的一跳
+type sshEndpoint struct {
+	user string
+	addr string
+}
+
+// parseSSHEndpoint 解析 [ssh://]user@host[:port]，用户名缺省为当前用户，端口缺省为 22
+func parseSSHEndpoint(s string) (sshEndpoint, error) {
+	if !strings.HasPrefix(s, "ssh://") {
+		s = "ssh://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return sshEndpoint{}, fmt.Errorf("invalid ssh host %q: %v", s, err)
+	}
+	if u.Hostname() == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+		return sshEndpoint{}, fmt.Errorf("invalid ssh host %q, expected ssh://user@host[:port]", s)
+	}
+	if _, ok := u.User.Password(); ok {
+		return sshEndpoint{}, fmt.Errorf("invalid ssh host %q: password authentication is not supported", s)
+	}
+
+	endpoint := sshEndpoint{user: u.User.Username()}
+	if endpoint.user == "" {
+		current, err := user.Current()
+		if err != nil {
+			return sshEndpoint{}, fmt.Errorf("no user specified in ssh host %q", s)
+		}
+		endpoint.user = current.Username
+	}
+	port := u.Port()
+	if port == "" {
+		port = defaultSSHPort
+	}
+	endpoint.addr = net.JoinHostPort(u.Hostname(), port)
+	return endpoint, nil
+}
+
+// validateSSHConfig 校验跳板机和私钥
+func validateSSHConfig(config *SSHConfig) error {
+	for _, jump := range config.JumpHosts {
+		if _, err := parseSSHEndpoint(jump); err != nil {
+			return err
+		}
+	}
+	if config.PrivateKey == "" {
+		return nil
+	}
+	if _, err := ssh.ParseRawPrivateKey([]byte(config.PrivateKey)); err != nil {
+		if _, ok := err.(*ssh.PassphraseMissingError); ok {
+			return fmt.Errorf("encrypted private keys are not supported, load the key into ssh-agent instead")
+		}
+		return fmt.Errorf("invalid ssh private key: %v", err)
+	}
+	return nil
+}
+
+// sshTunnel 一个 context 的 SSH 连接，经由跳板机连接到目标主机，
+// 所有 Docker API 请求复用同一条 SSH 连接，按需开启到远端 docker.sock 的通道
+type sshTunnel struct {
+	name       string
+	hops       []sshEndpoint
+	socketPath string
+	config     *ssh.ClientConfig
+	// signers 为 context 保存的私钥，为空时仅使用 ssh-agent
+	signers []ssh.Signer
+
+	mu sync.Mutex
+	// clients 依次为各跳板机和目标主机的连接，最后一个为目标主机
+	clients []*ssh.Client
+}
+
+// newSSHTunnel 根据 context 配置创建 SSH 通道，此时不建立连接
+func newSSHTunnel(name, host string, config *SSHConfig) (*sshTunnel, error) {
+	if config == nil {
+		config = &SSHConfig{}
+	}
+	target, err := parseSSHEndpoint(host)
+	if err != nil {
+		return nil, err
+	}
+	var hops []sshEndpoint
+	for _, jump := range config.JumpHosts {
+		endpoint, err := parseSSHEndpoint(jump)
+		if err != nil {
+			return nil, err
+		}
+		hops = append(hops, endpoint)
+	}
+	hops = append(hops, target)
+
+	hostKeyCallback, err := sshHostKeyCallback(config.KnownHosts)
+	if err != nil {
+		return nil, err
+	}
+	signers, err := sshSigners(name, config.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(signers) == 0 && os.Getenv("SSH_AUTH_SOCK") == "" {
+		return nil, fmt.Errorf("no ssh private key configured for context %s and SSH_AUTH_SOCK is not set", name)
+	}
+
+	socketPath := config.SocketPath
+	if socketPath == "" {
+		socketPath = defaultDockerSock
+	}
+	return &sshTunnel{
+		name:       name,
+		hops:       hops,
+		socketPath: socketPath,
+		signers:    signers,
+		config: &ssh.ClientConfig{
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         sshDialTimeout,
+		},
+	}, nil
+}
+
+// sshHostKeyCallback 使用 known_hosts 校验主机密钥
+func sshHostKeyCallback(path string) (ssh.HostKeyCallback, error) {
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate known_hosts: %v", err)
+		}
+		path = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	callback, err := knownhosts.New(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known_hosts %s: %v", path, err)
+	}
+	return callback, nil
+}
+
+// sshSigners 解析 context 保存的私钥，未保存时返回空
+func sshSigners(name, privateKey string) ([]ssh.Signer, error) {
+	if privateKey == "" {
+		return nil, nil
+	}
+	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ssh private key for context %s: %v", name, err)
+	}
+	return []ssh.Signer{signer}, nil
+}
+
+// DialContext 打开一条到远端 docker.sock 的通道，用作 Docker client 的拨号函数，
+// SSH 连接断开时重连一次
+func (t *sshTunnel) DialContext(ctx context.Context, _, _ string) (net.Conn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.clients != nil {
+		conn, err := t.clients[len(t.clients)-1].Dial("unix", t.socketPath)
+		if err == nil {
+			return conn, nil
+		}
+		t.closeLocked()
+	}
+
+	if err := t.connectLocked(ctx); err != nil {
+		return nil, err
+	}
+	conn, err := t.clients[len(t.clients)-1].Dial("unix", t.socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s over ssh: %v", t.socketPath, err)
+	}
+	return conn, nil
+}
+
+// connectLocked 依次连接跳板机和目标主机
+func (t *sshTunnel) connectLocked(ctx context.Context) error {
+	var auth []ssh.AuthMethod
+	if len(t.signers) > 0 {
+		auth = append(auth, ssh.PublicKeys(t.signers...))
+	}
+	// ssh-agent 连接需保持到握手完成，签名时仍会用到
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if agentConn, err := net.Dial("unix", sock); err == nil {
+			defer agentConn.Close()
+			auth = append(auth, ssh.PublicKeysCallback(agent.NewClient(agentConn).Signers))
+		}
+	}
+
+	var clients []*ssh.Client
+	for i, hop := range t.hops {
+		config := *t.config
+		config.User = hop.user
+		config.Auth = auth
+
+		var conn net.Conn
+		var err error
+		if i == 0 {
+			dialer := net.Dialer{Timeout: sshDialTimeout}
+			conn, err = dialer.DialContext(ctx, "tcp", hop.addr)
+		} else {
+			conn, err = clients[i-1].Dial("tcp", hop.addr)
+		}
+		if err != nil {
+			closeSSHClients(clients)
+			return fmt.Errorf("failed to connect to %s: %v", hop.addr, err)
+		}
+
+		c, chans, reqs, err := ssh.NewClientConn(conn, hop.addr, &config)
+		if err != nil {
+			conn.Close()
+			closeSSHClients(clients)
+			return fmt.Errorf("ssh handshake with %s failed: %v", hop.addr, err)
+		}
+		clients = append(clients, ssh.NewClient(c, chans, reqs))
+	}
+	t.clients = clients
+	return nil
+}
+
+// Close 关闭 SSH 连接
+func (t *sshTunnel) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closeLocked()
+	return nil
+}
+
+func (t *sshTunnel) closeLocked() {
+	closeSSHClients(t.clients)
+	t.clients = nil
+}
+
+// closeSSHClients 从目标主机开始逆序关闭连接
+func closeSSHClients(clients []*ssh.Client) {
+	for i := len(clients) - 1; i >= 0; i-- {
+		clients[i].Close()
+	}
+}
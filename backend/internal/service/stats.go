@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+const (
+	// DefaultStatsInterval 汇总统计的默认推送间隔，与 Docker 统计流的采样间隔一致
+	DefaultStatsInterval = time.Second
+	// MaxStatsInterval 汇总统计允许的最长推送间隔
+	MaxStatsInterval = time.Minute
+	// hostStatsRefresh 汇总统计重新列出运行中容器的间隔，用于发现新启动的容器
+	hostStatsRefresh = 10 * time.Second
+)
+
+// ContainerStats 容器某一时刻的资源统计，与 docker stats 的计算方式一致
+type ContainerStats struct {
+	ID   string    `json:"id"`
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+	// CPUPercent CPU 使用率，满载为 CPU 核数 * 100
+	CPUPercent float64 `json:"cpuPercent"`
+	// MemoryUsage 内存使用量（不含页缓存），MemoryLimit 为容器的内存限制，未限制时为主机内存
+	MemoryUsage   uint64  `json:"memoryUsage"`
+	MemoryLimit   uint64  `json:"memoryLimit"`
+	MemoryPercent float64 `json:"memoryPercent"`
+	// 网络和块设备 IO 为容器启动以来的累计字节数
+	NetworkRx  uint64 `json:"networkRx"`
+	NetworkTx  uint64 `json:"networkTx"`
+	BlockRead  uint64 `json:"blockRead"`
+	BlockWrite uint64 `json:"blockWrite"`
+	PIDs       uint64 `json:"pids"`
+}
+
+// HostStats 一个 context 中所有运行中容器的资源统计之和
+type HostStats struct {
+	Time time.Time `json:"time"`
+	// Containers 运行中的容器数量
+	Containers int `json:"containers"`
+	NCPU       int `json:"ncpu"`
+	// CPUPercent 各容器 CPU 使用率之和，满载为 NCPU * 100
+	CPUPercent  float64 `json:"cpuPercent"`
+	MemoryUsage uint64  `json:"memoryUsage"`
+	// MemoryTotal 主机内存，MemoryPercent 为容器内存使用量之和占主机内存的比例
+	MemoryTotal   uint64  `json:"memoryTotal"`
+	MemoryPercent float64 `json:"memoryPercent"`
+	NetworkRx     uint64  `json:"networkRx"`
+	NetworkTx     uint64  `json:"networkTx"`
+	BlockRead     uint64  `json:"blockRead"`
+	BlockWrite    uint64  `json:"blockWrite"`
+	PIDs          uint64  `json:"pids"`
+}
+
+// HostStatsSnapshot 汇总统计及参与汇总的每个容器的统计
+type HostStatsSnapshot struct {
+	Host       HostStats        `json:"host"`
+	Containers []ContainerStats `json:"containers"`
+}
+
+// convertDockerStats 将 Docker 的原始统计转换为 ContainerStats
+func convertDockerStats(raw types.StatsJSON) ContainerStats {
+	stats := ContainerStats{
+		ID:          shortID(raw.ID),
+		Name:        strings.TrimPrefix(raw.Name, "/"),
+		Time:        raw.Read,
+		CPUPercent:  dockerCPUPercent(raw.CPUStats, raw.PreCPUStats),
+		MemoryUsage: dockerMemoryUsage(raw.MemoryStats),
+		MemoryLimit: raw.MemoryStats.Limit,
+		PIDs:        raw.PidsStats.Current,
+	}
+	if stats.MemoryLimit > 0 {
+		stats.MemoryPercent = float64(stats.MemoryUsage) / float64(stats.MemoryLimit) * 100
+	}
+	for _, network := range raw.Networks {
+		stats.NetworkRx += network.RxBytes
+		stats.NetworkTx += network.TxBytes
+	}
+	for _, entry := range raw.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			stats.BlockRead += entry.Value
+		case "write":
+			stats.BlockWrite += entry.Value
+		}
+	}
+	return stats
+}
+
+// dockerCPUPercent 由相邻两次采样的 CPU 时间差计算使用率，第一次采样没有上一次的数据，结果为 0
+func dockerCPUPercent(cpu, precpu types.CPUStats) float64 {
+	cpuDelta := float64(cpu.CPUUsage.TotalUsage) - float64(precpu.CPUUsage.TotalUsage)
+	systemDelta := float64(cpu.SystemUsage) - float64(precpu.SystemUsage)
+	onlineCPUs := float64(cpu.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(cpu.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+	return cpuDelta / systemDelta * onlineCPUs * 100
+}
+
+// dockerMemoryUsage 内存使用量减去可回收的页缓存，cgroup v1 和 v2 的字段名不同
+func dockerMemoryUsage(mem types.MemoryStats) uint64 {
+	if v, ok := mem.Stats["total_inactive_file"]; ok && v < mem.Usage {
+		return mem.Usage - v
+	}
+	if v := mem.Stats["inactive_file"]; v < mem.Usage {
+		return mem.Usage - v
+	}
+	return mem.Usage
+}
+
+// GetContainerStats 读取容器当前的资源统计，需要等待 daemon 两次采样以计算 CPU 使用率，约需 1 到 2 秒
+func (s *DockerService) GetContainerStats(ctx context.Context, contextName string, id string) (stats ContainerStats, err error) {
+	defer logCall(ctx, contextName, "ContainerStats", time.Now(), &err)
+
+	rt, release, err := s.getRuntime(contextName)
+	if err != nil {
+		return ContainerStats{}, err
+	}
+	defer release()
+	err = rt.ContainerStats(ctx, id, false, func(sample ContainerStats) {
+		stats = sample
+	})
+	return stats, err
+}
+
+// StreamContainerStats 持续推送容器的资源统计，直到 ctx 取消或容器停止
+func (s *DockerService) StreamContainerStats(ctx context.Context, contextName string, id string, fn func(ContainerStats)) (err error) {
+	defer logCall(ctx, contextName, "ContainerStatsStream", time.Now(), &err)
+
+	rt, release, err := s.getRuntime(contextName)
+	if err != nil {
+		return err
+	}
+	defer release()
+	err = rt.ContainerStats(ctx, id, true, fn)
+	if ctx.Err() != nil {
+		// 客户端断开连接是正常结束
+		return nil
+	}
+	return err
+}
+
+// StreamHostStats 每隔 interval 推送一次 context 中所有运行中容器的统计及其汇总，直到 ctx 取消。
+// 每个运行中的容器各有一个统计流，新启动的容器在下一次重新列出时加入，停止的容器在统计流结束时移除
+func (s *DockerService) StreamHostStats(ctx context.Context, contextName string, interval time.Duration, fn func(HostStatsSnapshot)) (err error) {
+	defer logCall(ctx, contextName, "HostStatsStream", time.Now(), &err)
+
+	if interval <= 0 {
+		interval = DefaultStatsInterval
+	}
+	rt, release, err := s.getRuntime(contextName)
+	if err != nil {
+		return err
+	}
+	defer release()
+	info, err := rt.Info(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		latest = make(map[string]ContainerStats)
+		active = make(map[string]bool)
+		// streamErr 统计流因运行时不支持而失败时通知主循环
+		streamErr = make(chan error, 1)
+	)
+	// 先取消 ctx 再等待所有统计流退出
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	refresh := func() error {
+		containers, err := rt.ListContainers(ctx, ListFilter{Status: []string{"running"}})
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, c := range containers {
+			if active[c.ID] {
+				continue
+			}
+			active[c.ID] = true
+			wg.Add(1)
+			go func(id string) {
+				defer wg.Done()
+				err := rt.ContainerStats(ctx, id, true, func(sample ContainerStats) {
+					mu.Lock()
+					latest[id] = sample
+					mu.Unlock()
+				})
+				if errors.Is(err, ErrNotSupported) {
+					select {
+					case streamErr <- err:
+					default:
+					}
+				}
+				mu.Lock()
+				delete(active, id)
+				delete(latest, id)
+				mu.Unlock()
+			}(c.ID)
+		}
+		return nil
+	}
+
+	if err := refresh(); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	lastRefresh := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-streamErr:
+			return err
+		case now := <-ticker.C:
+			if now.Sub(lastRefresh) >= hostStatsRefresh {
+				if err := refresh(); err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+					return fmt.Errorf("failed to list running containers: %w", err)
+				}
+				lastRefresh = now
+			}
+			mu.Lock()
+			containers := make([]ContainerStats, 0, len(latest))
+			for _, stats := range latest {
+				containers = append(containers, stats)
+			}
+			mu.Unlock()
+			fn(aggregateStats(now, info, containers))
+		}
+	}
+}
+
+// aggregateStats 汇总容器统计，容器按名称排序
+func aggregateStats(now time.Time, info types.Info, containers []ContainerStats) HostStatsSnapshot {
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].Name < containers[j].Name
+	})
+	host := HostStats{
+		Time:        now,
+		Containers:  len(containers),
+		NCPU:        info.NCPU,
+		MemoryTotal: uint64(info.MemTotal),
+	}
+	for _, c := range containers {
+		host.CPUPercent += c.CPUPercent
+		host.MemoryUsage += c.MemoryUsage
+		host.NetworkRx += c.NetworkRx
+		host.NetworkTx += c.NetworkTx
+		host.BlockRead += c.BlockRead
+		host.BlockWrite += c.BlockWrite
+		host.PIDs += c.PIDs
+	}
+	if host.MemoryTotal > 0 {
+		host.MemoryPercent = float64(host.MemoryUsage) / float64(host.MemoryTotal) * 100
+	}
+	return HostStatsSnapshot{Host: host, Containers: containers}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/tlsconfig"
+)
+
+const (
+	caFileName   = "ca.pem"
+	certFileName = "cert.pem"
+	keyFileName  = "key.pem"
+)
+
+// 与 docker context 的命名规则一致，同时保证名称可以安全地用作目录名
+var contextNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.+-]*$`)
+
+// TLSConfig TCP 连接的 TLS 配置，对应 docker --tlsverify --tlscacert --tlscert --tlskey
+type TLSConfig struct {
+	// Verify 是否校验 daemon 证书，关闭时仍使用 TLS 但跳过校验（对应 docker --tls）
+	Verify bool `json:"verify"`
+	// CA/Cert/Key 为 PEM 内容，仅在创建或更新时提交，与 context 配置一起保存在存储后端中，不会在接口中返回。
+	// 更新时留空表示保留已保存的内容
+	CA   string `json:"ca,omitempty"`
+	Cert string `json:"cert,omitempty"`
+	Key  string `json:"key,omitempty"`
+}
+
+// CurrentContextAlias 在路由中代表当前 context 的名称，不能用作 context 名称
+const CurrentContextAlias = "current"
+
+// validateContextName 校验 context 名称
+func validateContextName(name string) error {
+	if !contextNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid context name %q: must match %s", name, contextNamePattern.String())
+	}
+	if name == CurrentContextAlias {
+		return fmt.Errorf("context name %q is reserved", name)
+	}
+	return nil
+}
+
+// validateTLSMaterial 校验 TLS 证书和私钥
+func validateTLSMaterial(config *TLSConfig) error {
+	if (config.Cert == "") != (config.Key == "") {
+		return fmt.Errorf("client certificate and key must be provided together")
+	}
+	if config.CA != "" {
+		if ok := x509.NewCertPool().AppendCertsFromPEM([]byte(config.CA)); !ok {
+			return fmt.Errorf("invalid CA certificate")
+		}
+	}
+	if config.Cert != "" {
+		if _, err := tls.X509KeyPair([]byte(config.Cert), []byte(config.Key)); err != nil {
+			return fmt.Errorf("invalid client certificate or key: %v", err)
+		}
+	}
+	return nil
+}
+
+// tlsClientOpt 根据保存的证书生成 Docker client 的 TLS 选项，需放在 WithHost 之前
+func tlsClientOpt(name string, config *TLSConfig) (client.Opt, error) {
+	tlsc := tlsconfig.ClientDefault()
+	tlsc.InsecureSkipVerify = !config.Verify
+	if config.CA != "" {
+		// 与 docker --tlscacert 一致，只信任指定的 CA
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(config.CA)) {
+			return nil, fmt.Errorf("failed to load tls config for context %s: invalid CA certificate", name)
+		}
+		tlsc.RootCAs = pool
+	}
+	if config.Cert != "" {
+		cert, err := tls.X509KeyPair([]byte(config.Cert), []byte(config.Key))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tls config for context %s: %v", name, err)
+		}
+		tlsc.Certificates = []tls.Certificate{cert}
+	}
+	return client.WithHTTPClient(&http.Client{
+		Transport:     &http.Transport{TLSClientConfig: tlsc},
+		CheckRedirect: client.CheckRedirect,
+	}), nil
+}
@@ -0,0 +1,222 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// jsonVersion contexts.json 当前的结构版本，没有 version 字段的旧文件视为版本 1
+const jsonVersion = 2
+
+// jsonMigrations 将 contexts.json 从键对应的版本升级到下一个版本
+var jsonMigrations = map[int]func(*ContextState) error{
+	1: migrateJSONV1,
+}
+
+// jsonFile contexts.json 的内容
+type jsonFile struct {
+	Version int `json:"version"`
+	*ContextState
+}
+
+// JSONBackend 以 contexts.json 保存状态。
+// 读写时持有文件锁，多个进程共享同一文件时也不会互相覆盖；写入先写临时文件再重命名，中途失败不会留下损坏的文件
+type JSONBackend struct {
+	path string
+	mu   sync.RWMutex
+}
+
+// NewJSONBackend 打开 path 处的 contexts.json，旧版本的文件会先备份再升级到当前版本
+func NewJSONBackend(path string) (*JSONBackend, error) {
+	s := &JSONBackend{path: path}
+	if err := s.migrate(); err != nil {
+		return nil, fmt.Errorf("failed to migrate %s: %v", path, err)
+	}
+	return s, nil
+}
+
+// LoadContexts 读取所有 context
+func (s *JSONBackend) LoadContexts() (*ContextState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	unlock, err := lockFile(s.path+".lock", false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	state, _, err := s.read()
+	return state, err
+}
+
+// UpdateContexts 在锁内读取所有 context 并交给 fn 修改
+func (s *JSONBackend) UpdateContexts(fn func(state *ContextState) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockFile(s.path+".lock", true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	state, _, err := s.read()
+	if err != nil {
+		return err
+	}
+	if err := fn(state); err != nil {
+		if errors.Is(err, ErrUnchanged) {
+			return nil
+		}
+		return err
+	}
+	return s.write(state)
+}
+
+// migrate 升级旧版本的 contexts.json，升级前保存一份 contexts.json.v<版本>.bak
+func (s *JSONBackend) migrate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockFile(s.path+".lock", true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	state, fromVersion, err := s.read()
+	if err != nil {
+		return err
+	}
+	if fromVersion == jsonVersion {
+		return nil
+	}
+
+	if fromVersion > 0 {
+		raw, err := os.ReadFile(s.path)
+		if err != nil {
+			return err
+		}
+		backup := fmt.Sprintf("%s.v%d.bak", s.path, fromVersion)
+		if err := os.WriteFile(backup, raw, 0600); err != nil {
+			return err
+		}
+		slog.Info("migrated context store", "path", s.path, "from", fromVersion, "to", jsonVersion, "backup", backup)
+	}
+	return s.write(state)
+}
+
+// Close 实现 Backend，JSON 文件不需要释放资源
+func (s *JSONBackend) Close() error {
+	return nil
+}
+
+// read 读取并升级配置，返回文件原本的版本，文件不存在时版本为 0
+func (s *JSONBackend) read() (*ContextState, int, error) {
+	return readJSONFile(s.path)
+}
+
+// readJSONFile 读取并升级 contexts.json
+func readJSONFile(path string) (*ContextState, int, error) {
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return NewContextState(), 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var probe struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	version := probe.Version
+	if version == 0 {
+		version = 1
+	}
+	if version > jsonVersion {
+		return nil, 0, fmt.Errorf("%s was written by a newer version (schema %d, supported %d)", path, version, jsonVersion)
+	}
+
+	file := jsonFile{ContextState: NewContextState()}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	if file.Contexts == nil {
+		file.Contexts = make(map[string]*Context)
+	}
+	for v := version; v < jsonVersion; v++ {
+		if err := jsonMigrations[v](file.ContextState); err != nil {
+			return nil, 0, err
+		}
+	}
+	return file.ContextState, version, nil
+}
+
+// write 先写入同目录下的临时文件，再原子地替换 contexts.json
+func (s *JSONBackend) write(state *ContextState) error {
+	content, err := json.MarshalIndent(jsonFile{Version: jsonVersion, ContextState: state}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	// 文件中包含 TLS 和 SSH 私钥，仅当前用户可读写
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// migrateJSONV1 升级无版本号的 contexts.json：
+// 补全缺失或无法识别的 type，清除指向不存在 context 的 current-context。
+// 没有 host 的 context 保留下来，由用户在界面中补全；只有内容为 null 的条目会被删除
+func migrateJSONV1(state *ContextState) error {
+	for name, stored := range state.Contexts {
+		if stored == nil {
+			slog.Warn("dropping empty context during migration", "context", name)
+			delete(state.Contexts, name)
+			continue
+		}
+		if stored.Host == "" {
+			slog.Warn("context has no host, keeping it for manual repair", "context", name)
+			continue
+		}
+		switch stored.Type {
+		case "tcp", "socket", "ssh":
+		default:
+			if contextType, err := TypeForHost(stored.Host); err == nil {
+				stored.Type = contextType
+			}
+		}
+	}
+	if _, ok := state.Contexts[state.CurrentContext]; !ok {
+		state.CurrentContext = ""
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJSONMigrateV1(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "contexts.json")
+	v1 := `{
+  "current-context": "broken",
+  "contexts": {
+    "local": {"host": "unix:///var/run/docker.sock"},
+    "remote": {"type": "unknown", "host": "tcp://10.0.0.1:2375"},
+    "broken": {"type": "tcp", "host": ""},
+    "empty": null
+  }
+}`
+	if err := os.WriteFile(path, []byte(v1), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	backend, err := NewJSONBackend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := backend.LoadContexts()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(state.Contexts) != 3 {
+		t.Fatalf("contexts = %v, want local, remote and broken", state.Contexts)
+	}
+	if got := state.Contexts["local"].Type; got != "socket" {
+		t.Errorf("local type = %q, want socket", got)
+	}
+	if got := state.Contexts["remote"].Type; got != "tcp" {
+		t.Errorf("remote type = %q, want tcp", got)
+	}
+	broken, ok := state.Contexts["broken"]
+	if !ok {
+		t.Fatal("context without host was dropped")
+	}
+	if broken.Type != "tcp" || broken.Host != "" {
+		t.Errorf("broken = %+v, want unchanged", broken)
+	}
+	if state.CurrentContext != "broken" {
+		t.Errorf("current context = %q, want broken", state.CurrentContext)
+	}
+	if _, err := os.Stat(path + ".v1.bak"); err != nil {
+		t.Errorf("backup not written: %v", err)
+	}
+}
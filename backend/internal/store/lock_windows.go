@@ -0,0 +1,8 @@
+//go:build windows
+
+package store
+
+// lockFile Windows 下不加文件锁，仅依靠进程内的互斥锁，不支持多个进程共享同一 contexts.json
+func lockFile(path string, exclusive bool) (func(), error) {
+	return func() {}, nil
+}
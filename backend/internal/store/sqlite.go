@@ -0,0 +1,263 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	_ "github.com/ncruces/go-sqlite3/driver"
+	_ "github.com/ncruces/go-sqlite3/embed"
+)
+
+// sqliteMigrations 依次执行的建表语句，已执行的版本记录在 PRAGMA user_version 中，只能追加不能修改
+var sqliteMigrations = []string{
+	// 1: context 和全局设置
+	`CREATE TABLE contexts (
+		name       TEXT PRIMARY KEY,
+		type       TEXT NOT NULL,
+		host       TEXT NOT NULL,
+		tls_verify INTEGER,
+		ssh        TEXT,
+		source     TEXT NOT NULL DEFAULT ''
+	);
+	CREATE TABLE settings (
+		key   TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);`,
+	// 2: 标签和分组，均以 JSON 保存
+	`ALTER TABLE contexts ADD COLUMN labels TEXT;
+	ALTER TABLE contexts ADD COLUMN group_names TEXT;`,
+	// 3: containerd 的 namespace
+	`ALTER TABLE contexts ADD COLUMN namespace TEXT NOT NULL DEFAULT '';`,
+	// 4: TLS 证书和私钥，SSH 私钥保存在 ssh 列的 JSON 中
+	`ALTER TABLE contexts ADD COLUMN tls_ca TEXT NOT NULL DEFAULT '';
+	ALTER TABLE contexts ADD COLUMN tls_cert TEXT NOT NULL DEFAULT '';
+	ALTER TABLE contexts ADD COLUMN tls_key TEXT NOT NULL DEFAULT '';`,
+}
+
+const settingCurrentContext = "current-context"
+
+// SQLiteBackend 以内嵌 SQLite 数据库保存状态，写入使用 IMMEDIATE 事务，多个进程可共享同一数据库文件
+type SQLiteBackend struct {
+	db *sql.DB
+	// created 表示数据库是本次新建的
+	created bool
+}
+
+// NewSQLiteBackend 打开 path 处的数据库并执行未执行过的迁移
+func NewSQLiteBackend(path string) (*SQLiteBackend, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_txlock=immediate&_pragma=busy_timeout(10000)&_pragma=journal_mode(wal)")
+	if err != nil {
+		return nil, err
+	}
+	s := &SQLiteBackend{db: db}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate %s: %v", path, err)
+	}
+	// 数据库中包含 TLS 和 SSH 私钥，仅当前用户可读写，WAL 文件沿用数据库文件的权限
+	if err := os.Chmod(path, 0600); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// migrate 在同一事务中检查并执行迁移，多个实例同时启动时只有一个会执行
+func (s *SQLiteBackend) migrate() error {
+	return s.inTx(false, func(tx *sql.Tx) error {
+		var version int
+		if err := tx.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+			return err
+		}
+		if version > len(sqliteMigrations) {
+			return fmt.Errorf("database was written by a newer version (schema %d, supported %d)", version, len(sqliteMigrations))
+		}
+		s.created = version == 0
+		for v := version; v < len(sqliteMigrations); v++ {
+			if _, err := tx.Exec(sqliteMigrations[v]); err != nil {
+				return fmt.Errorf("migration %d: %v", v+1, err)
+			}
+			slog.Info("applied store migration", "driver", DriverSQLite, "version", v+1)
+		}
+		_, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", len(sqliteMigrations)))
+		return err
+	})
+}
+
+// importJSON 新建数据库时导入同一数据目录下已有的 contexts.json
+func (s *SQLiteBackend) importJSON(path string) error {
+	if !s.created {
+		return nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	state, _, err := readJSONFile(path)
+	if err != nil {
+		return err
+	}
+	err = s.UpdateContexts(func(current *ContextState) error {
+		if len(current.Contexts) > 0 {
+			return ErrUnchanged
+		}
+		*current = *state
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	slog.Info("imported contexts into sqlite store", "from", path, "contexts", len(state.Contexts))
+	return nil
+}
+
+// LoadContexts 读取所有 context
+func (s *SQLiteBackend) LoadContexts() (*ContextState, error) {
+	var state *ContextState
+	err := s.inTx(true, func(tx *sql.Tx) error {
+		var err error
+		state, err = loadContexts(tx)
+		return err
+	})
+	return state, err
+}
+
+// UpdateContexts 在事务中读取所有 context 并交给 fn 修改
+func (s *SQLiteBackend) UpdateContexts(fn func(state *ContextState) error) error {
+	err := s.inTx(false, func(tx *sql.Tx) error {
+		state, err := loadContexts(tx)
+		if err != nil {
+			return err
+		}
+		if err := fn(state); err != nil {
+			return err
+		}
+		return saveContexts(tx, state)
+	})
+	if errors.Is(err, ErrUnchanged) {
+		return nil
+	}
+	return err
+}
+
+// Close 关闭数据库
+func (s *SQLiteBackend) Close() error {
+	return s.db.Close()
+}
+
+// inTx 在事务中执行 fn，fn 返回错误时回滚
+func (s *SQLiteBackend) inTx(readOnly bool, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: readOnly})
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func loadContexts(tx *sql.Tx) (*ContextState, error) {
+	state := NewContextState()
+
+	err := tx.QueryRow("SELECT value FROM settings WHERE key = ?", settingCurrentContext).Scan(&state.CurrentContext)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	rows, err := tx.Query("SELECT name, type, host, tls_verify, tls_ca, tls_cert, tls_key, ssh, source, labels, group_names, namespace FROM contexts")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			name      string
+			c         Context
+			tlsVerify sql.NullBool
+			tls       TLS
+			sshConfig sql.NullString
+			labels    sql.NullString
+			groups    sql.NullString
+		)
+		if err := rows.Scan(&name, &c.Type, &c.Host, &tlsVerify, &tls.CA, &tls.Cert, &tls.Key, &sshConfig, &c.Source, &labels, &groups, &c.Namespace); err != nil {
+			return nil, err
+		}
+		if tlsVerify.Valid {
+			tls.Verify = tlsVerify.Bool
+			c.TLS = &tls
+		}
+		if sshConfig.Valid {
+			c.SSH = &SSH{}
+			if err := json.Unmarshal([]byte(sshConfig.String), c.SSH); err != nil {
+				return nil, fmt.Errorf("invalid ssh config for context %s: %v", name, err)
+			}
+		}
+		if labels.Valid {
+			if err := json.Unmarshal([]byte(labels.String), &c.Labels); err != nil {
+				return nil, fmt.Errorf("invalid labels for context %s: %v", name, err)
+			}
+		}
+		if groups.Valid {
+			if err := json.Unmarshal([]byte(groups.String), &c.Groups); err != nil {
+				return nil, fmt.Errorf("invalid groups for context %s: %v", name, err)
+			}
+		}
+		state.Contexts[name] = &c
+	}
+	return state, rows.Err()
+}
+
+// saveContexts 用 state 替换表中的全部 context
+func saveContexts(tx *sql.Tx, state *ContextState) error {
+	if _, err := tx.Exec("DELETE FROM contexts"); err != nil {
+		return err
+	}
+	for name, c := range state.Contexts {
+		var (
+			tlsVerify sql.NullBool
+			tls       TLS
+		)
+		if c.TLS != nil {
+			tlsVerify = sql.NullBool{Bool: c.TLS.Verify, Valid: true}
+			tls = *c.TLS
+		}
+		sshConfig, err := nullJSON(c.SSH, c.SSH != nil)
+		if err != nil {
+			return err
+		}
+		labels, err := nullJSON(c.Labels, len(c.Labels) > 0)
+		if err != nil {
+			return err
+		}
+		groups, err := nullJSON(c.Groups, len(c.Groups) > 0)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("INSERT INTO contexts (name, type, host, tls_verify, tls_ca, tls_cert, tls_key, ssh, source, labels, group_names, namespace) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			name, c.Type, c.Host, tlsVerify, tls.CA, tls.Cert, tls.Key, sshConfig, c.Source, labels, groups, c.Namespace)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec("INSERT INTO settings (key, value) VALUES (?, ?) ON CONFLICT (key) DO UPDATE SET value = excluded.value",
+		settingCurrentContext, state.CurrentContext)
+	return err
+}
+
+// nullJSON 将 v 编码为 JSON，valid 为 false 时保存为 NULL
+func nullJSON(v any, valid bool) (sql.NullString, error) {
+	if !valid {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
@@ -0,0 +1,216 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// openSQLiteAt 创建只执行了前 version 个迁移的数据库，模拟旧版本写入的数据
+func openSQLiteAt(t *testing.T, path string, version int) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	for v := 0; v < version; v++ {
+		if _, err := db.Exec(sqliteMigrations[v]); err != nil {
+			t.Fatalf("migration %d: %v", v+1, err)
+		}
+	}
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", version)); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func userVersion(t *testing.T, s *SQLiteBackend) int {
+	t.Helper()
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	return version
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	for version := 0; version <= len(sqliteMigrations); version++ {
+		t.Run(fmt.Sprintf("from %d", version), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), sqliteFileName)
+			db := openSQLiteAt(t, path, version)
+			want := NewContextState()
+			if version >= 1 {
+				// 版本 1 的列在之后的迁移中保持不变
+				_, err := db.Exec(`INSERT INTO contexts (name, type, host, tls_verify, ssh, source) VALUES
+					('remote', 'tcp', 'tcp://10.0.0.1:2376', 1, NULL, ''),
+					('jump', 'ssh', 'ssh://user@host', NULL, '{"jumpHosts":["bastion"]}', 'docker-cli');
+					INSERT INTO settings (key, value) VALUES ('current-context', 'remote');`)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want.CurrentContext = "remote"
+				want.Contexts["remote"] = &Context{Type: "tcp", Host: "tcp://10.0.0.1:2376", TLS: &TLS{Verify: true}}
+				want.Contexts["jump"] = &Context{Type: "ssh", Host: "ssh://user@host", SSH: &SSH{JumpHosts: []string{"bastion"}}, Source: "docker-cli"}
+			}
+			db.Close()
+
+			backend, err := NewSQLiteBackend(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer backend.Close()
+			if got := userVersion(t, backend); got != len(sqliteMigrations) {
+				t.Errorf("user_version = %d, want %d", got, len(sqliteMigrations))
+			}
+			if backend.created != (version == 0) {
+				t.Errorf("created = %v, want %v", backend.created, version == 0)
+			}
+			state, err := backend.LoadContexts()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(state, want) {
+				t.Errorf("state = %+v, want %+v", state, want)
+			}
+		})
+	}
+}
+
+func TestSQLiteNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), sqliteFileName)
+	db := openSQLiteAt(t, path, len(sqliteMigrations))
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", len(sqliteMigrations)+1)); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	if backend, err := NewSQLiteBackend(path); err == nil {
+		backend.Close()
+		t.Fatal("expected an error for a database written by a newer version")
+	}
+}
+
+func TestSQLiteRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), sqliteFileName)
+	backend, err := NewSQLiteBackend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ContextState{
+		CurrentContext: "secure",
+		Contexts: map[string]*Context{
+			"secure": {
+				Type:   "tcp",
+				Host:   "tcp://10.0.0.1:2376",
+				TLS:    &TLS{Verify: true, CA: "ca pem", Cert: "cert pem", Key: "key pem"},
+				Labels: map[string]string{"env": "prod"},
+				Groups: []string{"edge"},
+			},
+			"insecure": {Type: "tcp", Host: "tcp://10.0.0.2:2376", TLS: &TLS{CA: "ca pem"}},
+			"plain":    {Type: "tcp", Host: "tcp://10.0.0.3:2375"},
+			"remote": {
+				Type: "ssh",
+				Host: "ssh://user@host",
+				SSH:  &SSH{KnownHosts: "/root/.ssh/known_hosts", PrivateKey: "private key pem"},
+			},
+			"k8s": {Type: "containerd", Host: "unix:///run/containerd/containerd.sock", Namespace: "k8s.io"},
+		},
+	}
+	err = backend.UpdateContexts(func(state *ContextState) error {
+		*state = *want
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend.Close()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("database permissions = %o, want 600", perm)
+	}
+
+	// 重新打开，确认证书和私钥写入了数据库
+	backend, err = NewSQLiteBackend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Close()
+	state, err := backend.LoadContexts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(state, want) {
+		t.Errorf("state = %+v, want %+v", state, want)
+	}
+}
+
+func TestSQLiteImportJSON(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, jsonFileName)
+	// 版本 1 的 contexts.json 导入前会先升级
+	v1 := `{
+  "current-context": "secure",
+  "contexts": {
+    "local": {"host": "unix:///var/run/docker.sock"},
+    "secure": {"type": "tcp", "host": "tcp://10.0.0.1:2376", "tls": {"verify": true, "ca": "ca pem", "cert": "cert pem", "key": "key pem"}},
+    "remote": {"type": "ssh", "host": "ssh://user@host", "ssh": {"privateKey": "private key pem"}}
+  }
+}`
+	if err := os.WriteFile(jsonPath, []byte(v1), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	backend, err := Open(Config{Driver: DriverSQLite, Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := backend.LoadContexts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ContextState{
+		CurrentContext: "secure",
+		Contexts: map[string]*Context{
+			"local":  {Type: "socket", Host: "unix:///var/run/docker.sock"},
+			"secure": {Type: "tcp", Host: "tcp://10.0.0.1:2376", TLS: &TLS{Verify: true, CA: "ca pem", Cert: "cert pem", Key: "key pem"}},
+			"remote": {Type: "ssh", Host: "ssh://user@host", SSH: &SSH{PrivateKey: "private key pem"}},
+		},
+	}
+	if !reflect.DeepEqual(state, want) {
+		t.Errorf("imported state = %+v, want %+v", state, want)
+	}
+
+	// 只在新建数据库时导入，之后 contexts.json 的修改不会覆盖数据库
+	err = backend.UpdateContexts(func(state *ContextState) error {
+		delete(state.Contexts, "local")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend.Close()
+
+	backend, err = Open(Config{Driver: DriverSQLite, Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Close()
+	state, err = backend.LoadContexts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := state.Contexts["local"]; ok {
+		t.Error("contexts.json was imported again into an existing database")
+	}
+	if len(state.Contexts) != 2 {
+		t.Errorf("contexts = %v, want secure and remote", state.Contexts)
+	}
+}
@@ -0,0 +1,123 @@
+// Package store 提供服务端状态的持久化后端，目前保存 context 配置，
+// 支持 JSON 文件和内嵌 SQLite 两种实现
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DriverJSON 以 JSON 文件保存，适合单实例部署
+	DriverJSON = "json"
+	// DriverSQLite 以内嵌 SQLite 数据库保存，多个实例可共享同一数据目录
+	DriverSQLite = "sqlite"
+
+	jsonFileName   = "contexts.json"
+	sqliteFileName = "state.db"
+)
+
+// ErrUnchanged 由 Update 系列方法的回调返回，表示没有修改，不需要写入
+var ErrUnchanged = errors.New("store unchanged")
+
+// TLS context 的 TLS 设置。证书和私钥为 PEM 内容，与配置一起保存，多个实例共享存储时都能使用
+type TLS struct {
+	Verify bool   `json:"verify"`
+	CA     string `json:"ca,omitempty"`
+	Cert   string `json:"cert,omitempty"`
+	Key    string `json:"key,omitempty"`
+}
+
+// SSH context 的 SSH 设置，PrivateKey 为 PEM 格式的私钥，为空时使用 ssh-agent
+type SSH struct {
+	KnownHosts string   `json:"knownHosts,omitempty"`
+	JumpHosts  []string `json:"jumpHosts,omitempty"`
+	SocketPath string   `json:"socketPath,omitempty"`
+	PrivateKey string   `json:"privateKey,omitempty"`
+}
+
+// Context 保存的一个 context
+type Context struct {
+	Type   string `json:"type"`
+	Host   string `json:"host"`
+	TLS    *TLS   `json:"tls,omitempty"`
+	SSH    *SSH   `json:"ssh,omitempty"`
+	Source string `json:"source,omitempty"`
+	// Labels 和 Groups 用于筛选和跨 context 查询
+	Labels map[string]string `json:"labels,omitempty"`
+	Groups []string          `json:"groups,omitempty"`
+	// Namespace 仅用于 containerd 类型
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ContextState 所有 context 及当前 context
+type ContextState struct {
+	CurrentContext string              `json:"current-context"`
+	Contexts       map[string]*Context `json:"contexts"`
+}
+
+// NewContextState 返回空的 ContextState
+func NewContextState() *ContextState {
+	return &ContextState{Contexts: make(map[string]*Context)}
+}
+
+// Backend 持久化后端，各实现需保证 Update 系列方法在多个进程间互斥
+type Backend interface {
+	// LoadContexts 读取所有 context
+	LoadContexts() (*ContextState, error)
+	// UpdateContexts 读取所有 context 并交给 fn 修改，fn 返回错误时不写入，返回 ErrUnchanged 时不写入也不报错
+	UpdateContexts(fn func(state *ContextState) error) error
+	// Close 释放后端占用的资源
+	Close() error
+}
+
+// Config 后端配置
+type Config struct {
+	// Driver 为 json 或 sqlite，默认为 json
+	Driver string
+	// Dir 数据目录，保存 contexts.json 或 state.db
+	Dir string
+}
+
+// Open 按配置打开后端，数据目录不存在时自动创建
+func Open(cfg Config) (Backend, error) {
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return nil, err
+	}
+	jsonPath := filepath.Join(cfg.Dir, jsonFileName)
+
+	switch strings.ToLower(cfg.Driver) {
+	case "", DriverJSON:
+		return NewJSONBackend(jsonPath)
+	case DriverSQLite:
+		backend, err := NewSQLiteBackend(filepath.Join(cfg.Dir, sqliteFileName))
+		if err != nil {
+			return nil, err
+		}
+		// 从 JSON 切换到 SQLite 时导入已有的 contexts.json
+		if err := backend.importJSON(jsonPath); err != nil {
+			backend.Close()
+			return nil, err
+		}
+		return backend, nil
+	default:
+		return nil, fmt.Errorf("unknown store driver %q, expected %s or %s", cfg.Driver, DriverJSON, DriverSQLite)
+	}
+}
+
+// TypeForHost 根据 host 的协议推断 context 类型
+func TypeForHost(host string) (string, error) {
+	switch {
+	case strings.HasPrefix(host, "tcp://"):
+		return "tcp", nil
+	case strings.HasPrefix(host, "unix://"):
+		return "socket", nil
+	case strings.HasPrefix(host, "ssh://"):
+		return "ssh", nil
+	default:
+		return "", fmt.Errorf("unsupported docker host %q", host)
+	}
+}
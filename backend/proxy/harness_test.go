@@ -0,0 +1,274 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/distribution/distribution/v3/configuration"
+	"github.com/distribution/distribution/v3/registry/handlers"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMain(m *testing.M) {
+	logrus.SetLevel(logrus.ErrorLevel)
+	os.Exit(m.Run())
+}
+
+// fakeUpstream 进程内的上游 registry，记录收到的请求并可选开启 basic 认证
+type fakeUpstream struct {
+	*httptest.Server
+
+	username string
+	password string
+
+	mu       sync.Mutex
+	requests map[string]int
+}
+
+func newFakeUpstream(t *testing.T, username, password string) *fakeUpstream {
+	t.Helper()
+
+	config := &configuration.Configuration{}
+	config.Storage = map[string]configuration.Parameters{"inmemory": map[string]interface{}{}}
+	config.Log.AccessLog.Disabled = true
+	app := handlers.NewApp(context.Background(), config)
+
+	u := &fakeUpstream{
+		username: username,
+		password: password,
+		requests: make(map[string]int),
+	}
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u.username != "" {
+			user, pass, ok := r.BasicAuth()
+			if !ok || user != u.username || pass != u.password {
+				w.Header().Set("WWW-Authenticate", `Basic realm="fake-upstream"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+		u.mu.Lock()
+		u.requests[r.Method+" "+r.URL.Path]++
+		u.mu.Unlock()
+		app.ServeHTTP(w, r)
+	}))
+	t.Cleanup(u.Close)
+	return u
+}
+
+// count 返回已认证请求中匹配 method 和 path 的次数
+func (u *fakeUpstream) count(method, path string) int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.requests[method+" "+path]
+}
+
+// client 返回携带上游认证信息的 HTTP 客户端
+func (u *fakeUpstream) client() *http.Client {
+	return &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if u.username != "" {
+			r.SetBasicAuth(u.username, u.password)
+		}
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// testImage 推送到上游的测试镜像
+type testImage struct {
+	repo     string
+	tag      string
+	manifest digest.Digest
+	config   digest.Digest
+	layer    digest.Digest
+	content  []byte
+}
+
+// pushImage 向上游推送一个单层镜像
+func (u *fakeUpstream) pushImage(t *testing.T, repo, tag string, layer []byte) testImage {
+	t.Helper()
+
+	configBlob := []byte(fmt.Sprintf(`{"architecture":"amd64","os":"linux","rootfs":{"type":"layers","diff_ids":["%s"]}}`, digest.FromBytes(layer)))
+	img := testImage{
+		repo:    repo,
+		tag:     tag,
+		config:  u.pushBlob(t, repo, configBlob),
+		layer:   u.pushBlob(t, repo, layer),
+		content: layer,
+	}
+
+	manifest := v1.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: v1.MediaTypeImageManifest,
+		Config: v1.Descriptor{
+			MediaType: v1.MediaTypeImageConfig,
+			Digest:    img.config,
+			Size:      int64(len(configBlob)),
+		},
+		Layers: []v1.Descriptor{{
+			MediaType: v1.MediaTypeImageLayerGzip,
+			Digest:    img.layer,
+			Size:      int64(len(layer)),
+		}},
+	}
+	payload, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/v2/%s/manifests/%s", u.URL, repo, tag), bytes.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", v1.MediaTypeImageManifest)
+	resp := mustDo(t, u.client(), req, http.StatusCreated)
+	resp.Body.Close()
+	img.manifest = digest.FromBytes(payload)
+	return img
+}
+
+// pushBlob 以单次上传的方式推送 blob
+func (u *fakeUpstream) pushBlob(t *testing.T, repo string, data []byte) digest.Digest {
+	t.Helper()
+
+	dgst := digest.FromBytes(data)
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/v2/%s/blobs/uploads/", u.URL, repo), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := mustDo(t, u.client(), req, http.StatusAccepted)
+	resp.Body.Close()
+
+	location, err := resp.Location()
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := location.Query()
+	q.Set("digest", dgst.String())
+	location.RawQuery = q.Encode()
+
+	req, err = http.NewRequest(http.MethodPut, location.String(), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp = mustDo(t, u.client(), req, http.StatusCreated)
+	resp.Body.Close()
+	return dgst
+}
+
+// newTestProxy 在临时端口启动指向 upstream 的代理
+func newTestProxy(t *testing.T, upstream *fakeUpstream, proxyCfg configuration.Proxy) *httptest.Server {
+	t.Helper()
+
+	proxyCfg.RemoteURL = upstream.URL
+	return newTestProxyFor(t, proxyCfg)
+}
+
+// newTestProxyFor 启动一个以 proxyCfg.RemoteURL 为上游的代理
+func newTestProxyFor(t *testing.T, proxyCfg configuration.Proxy) *httptest.Server {
+	t.Helper()
+
+	config := newConfiguration(nil, "", &proxyCfg, &Options{LogLevel: "error"})
+	app := handlers.NewApp(context.Background(), config)
+	srv := httptest.NewServer(app)
+	t.Cleanup(func() {
+		srv.Close()
+		app.Shutdown()
+	})
+	return srv
+}
+
+// getManifest 通过代理拉取 manifest，返回内容 digest
+func getManifest(t *testing.T, base, repo, reference string) digest.Digest {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/v2/%s/manifests/%s", base, repo, reference), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", manifestAccept)
+	resp := mustDo(t, http.DefaultClient, req, http.StatusOK)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return digest.FromBytes(body)
+}
+
+// getBlob 通过代理拉取 blob
+func getBlob(t *testing.T, base, repo string, dgst digest.Digest) []byte {
+	t.Helper()
+
+	body, status, err := tryGetBlob(base, repo, dgst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != http.StatusOK {
+		t.Fatalf("GET blob %s: unexpected status %d", dgst, status)
+	}
+	return body
+}
+
+func tryGetBlob(base, repo string, dgst digest.Digest) ([]byte, int, error) {
+	resp, err := http.Get(fmt.Sprintf("%s/v2/%s/blobs/%s", base, repo, dgst))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return body, resp.StatusCode, err
+}
+
+func mustDo(t *testing.T, client *http.Client, req *http.Request, want int) *http.Response {
+	t.Helper()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != want {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		t.Fatalf("%s %s: got status %d, want %d: %s", req.Method, req.URL.Path, resp.StatusCode, want, strings.TrimSpace(string(body)))
+	}
+	return resp
+}
+
+// blobPath 返回 blob 的请求路径，用于统计上游请求次数
+func blobPath(repo string, dgst digest.Digest) string {
+	return fmt.Sprintf("/v2/%s/blobs/%s", repo, dgst)
+}
+
+// eventually 在 timeout 内轮询 cond，直到返回 true
+func eventually(t *testing.T, timeout time.Duration, cond func() bool, msg string) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal(msg)
+}
@@ -0,0 +1,421 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/distribution/distribution/v3/configuration"
+	"github.com/docker/go-metrics"
+	"github.com/docker/go-units"
+	"golang.org/x/time/rate"
+)
+
+// 限速读取时单次等待的最大字节数，同时作为令牌桶容量
+const limitChunkSize = 32 * 1024
+
+var (
+	limitNamespace = metrics.NewNamespace("registry", "agent", nil)
+	// queuedRequests 等待上游连接名额的请求数
+	queuedRequests = limitNamespace.NewGauge("upstream_queued_requests", "The number of upstream requests waiting for a connection slot", metrics.Total)
+	// activeRequests 正在占用上游连接名额的请求数
+	activeRequests = limitNamespace.NewGauge("upstream_active_requests", "The number of upstream requests holding a connection slot", metrics.Total)
+	// queueWait 请求等待连接名额的耗时
+	queueWait = limitNamespace.NewTimer("upstream_queue_wait", "The time upstream requests spent waiting for a connection slot")
+	// upstreamBytes 从各上游读取的字节数
+	upstreamBytes = limitNamespace.NewLabeledCounter("upstream_read_bytes", "The number of bytes read from each upstream", "upstream")
+)
+
+func init() {
+	metrics.Register(limitNamespace)
+}
+
+// BandwidthSchedule 在指定时段内替换全局上游带宽限制
+type BandwidthSchedule struct {
+	// Days 生效的星期，为空表示每天
+	Days []time.Weekday
+	// Start/End 为当天的分钟偏移，End 小于 Start 表示跨越午夜
+	Start int
+	End   int
+	// BytesPerSecond 时段内的带宽上限，0 表示不限速
+	BytesPerSecond int64
+}
+
+// Limits 上游带宽和连接数限制
+type Limits struct {
+	// BytesPerSecond 所有上游合计的带宽上限，0 表示不限速
+	BytesPerSecond int64
+	// UpstreamBytesPerSecond 按上游主机名设置的带宽上限
+	UpstreamBytesPerSecond map[string]int64
+	// MaxConnections 同时进行的上游请求数上限，0 表示不限制
+	MaxConnections int
+	// Schedules 按时段覆盖 BytesPerSecond，按顺序取第一个匹配项
+	Schedules []BandwidthSchedule
+}
+
+// active 判断时段是否覆盖 t
+func (s BandwidthSchedule) active(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	if s.End < s.Start {
+		// 跨越午夜的时段，凌晨部分属于前一天的安排
+		if minute < s.End {
+			day = (day + 6) % 7
+		} else if minute < s.Start {
+			return false
+		}
+	} else if minute < s.Start || minute >= s.End {
+		return false
+	}
+	if len(s.Days) == 0 {
+		return true
+	}
+	for _, d := range s.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// bytesLimit 将字节速率转换为令牌桶限速，0 表示不限速
+func bytesLimit(bytesPerSecond int64) rate.Limit {
+	if bytesPerSecond <= 0 {
+		return rate.Inf
+	}
+	return rate.Limit(bytesPerSecond)
+}
+
+// limitedTransport 对上游请求进行并发控制和带宽限速
+type limitedTransport struct {
+	next   http.RoundTripper
+	limits Limits
+	now    func() time.Time
+
+	slots chan struct{}
+
+	global *rate.Limiter
+
+	mu        sync.Mutex
+	upstreams map[string]*rate.Limiter
+}
+
+// NewLimitedTransport 返回按 limits 限制并发和带宽的 RoundTripper
+func NewLimitedTransport(next http.RoundTripper, limits Limits) http.RoundTripper {
+	t := &limitedTransport{
+		next:      next,
+		limits:    limits,
+		now:       time.Now,
+		global:    rate.NewLimiter(bytesLimit(limits.BytesPerSecond), limitChunkSize),
+		upstreams: make(map[string]*rate.Limiter),
+	}
+	if limits.MaxConnections > 0 {
+		t.slots = make(chan struct{}, limits.MaxConnections)
+	}
+	for host, bps := range limits.UpstreamBytesPerSecond {
+		t.upstreams[host] = rate.NewLimiter(bytesLimit(bps), limitChunkSize)
+	}
+	return t
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := t.acquire(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	// 连接名额在响应体读完或关闭后才释放，blob 下载期间一直占用
+	resp.Body = &limitedBody{
+		ctx:      req.Context(),
+		body:     resp.Body,
+		t:        t,
+		upstream: t.upstreamLimiter(req.URL.Hostname()),
+		bytes:    upstreamBytes.WithValues(req.URL.Hostname()),
+		release:  release,
+	}
+	return resp, nil
+}
+
+// acquire 获取一个上游连接名额，名额用尽时排队等待
+func (t *limitedTransport) acquire(ctx context.Context) (func(), error) {
+	if t.slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case t.slots <- struct{}{}:
+	default:
+		queuedRequests.Inc(1)
+		start := time.Now()
+		select {
+		case t.slots <- struct{}{}:
+			queuedRequests.Dec(1)
+			queueWait.UpdateSince(start)
+		case <-ctx.Done():
+			queuedRequests.Dec(1)
+			return nil, ctx.Err()
+		}
+	}
+
+	activeRequests.Inc(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			activeRequests.Dec(1)
+			<-t.slots
+		})
+	}, nil
+}
+
+func (t *limitedTransport) upstreamLimiter(host string) *rate.Limiter {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.upstreams[host]
+}
+
+// globalLimiter 返回按当前时段调整过速率的全局限速器
+func (t *limitedTransport) globalLimiter() *rate.Limiter {
+	limit := bytesLimit(t.limits.BytesPerSecond)
+	now := t.now()
+	for _, s := range t.limits.Schedules {
+		if s.active(now) {
+			limit = bytesLimit(s.BytesPerSecond)
+			break
+		}
+	}
+	if t.global.Limit() != limit {
+		t.global.SetLimitAt(now, limit)
+	}
+	return t.global
+}
+
+// limitedBody 按限速读取上游响应体
+type limitedBody struct {
+	ctx      context.Context
+	body     io.ReadCloser
+	t        *limitedTransport
+	upstream *rate.Limiter
+	bytes    metrics.Counter
+	release  func()
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if len(p) > limitChunkSize {
+		p = p[:limitChunkSize]
+	}
+	n, err := b.body.Read(p)
+	if n > 0 {
+		b.bytes.Inc(float64(n))
+		if werr := b.wait(n); werr != nil {
+			return n, werr
+		}
+	}
+	if err == io.EOF {
+		b.release()
+	}
+	return n, err
+}
+
+func (b *limitedBody) wait(n int) error {
+	if err := b.t.globalLimiter().WaitN(b.ctx, n); err != nil {
+		return err
+	}
+	if b.upstream != nil {
+		return b.upstream.WaitN(b.ctx, n)
+	}
+	return nil
+}
+
+func (b *limitedBody) Close() error {
+	defer b.release()
+	return b.body.Close()
+}
+
+// ParseBandwidth 解析带宽配置，如 "10MB" 表示每秒 10MB，空字符串或 "0" 表示不限速
+func ParseBandwidth(s string) (int64, error) {
+	s = strings.TrimSuffix(strings.TrimSpace(s), "/s")
+	if s == "" || s == "0" {
+		return 0, nil
+	}
+	n, err := units.RAMInBytes(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid bandwidth %q: %v", s, err)
+	}
+	return n, nil
+}
+
+// ParseUpstreamBandwidths 解析按上游设置的带宽，格式为 "host=10MB,host2=5MB"
+func ParseUpstreamBandwidths(s string) (map[string]int64, error) {
+	result := make(map[string]int64)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		host, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid upstream bandwidth %q, expected host=rate", item)
+		}
+		bps, err := ParseBandwidth(value)
+		if err != nil {
+			return nil, err
+		}
+		result[strings.TrimSpace(host)] = bps
+	}
+	return result, nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseBandwidthSchedules 解析时段限速，多个时段以 ";" 分隔，
+// 每个时段格式为 "[days ]HH:MM-HH:MM=rate"，如 "mon-fri 09:00-18:00=2MB;sat,sun 00:00-24:00=0"
+func ParseBandwidthSchedules(s string) ([]BandwidthSchedule, error) {
+	var schedules []BandwidthSchedule
+	for _, item := range strings.Split(s, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		window, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid schedule %q, expected [days ]HH:MM-HH:MM=rate", item)
+		}
+
+		var schedule BandwidthSchedule
+		fields := strings.Fields(window)
+		switch len(fields) {
+		case 1:
+		case 2:
+			days, err := parseWeekdays(fields[0])
+			if err != nil {
+				return nil, err
+			}
+			schedule.Days = days
+			fields = fields[1:]
+		default:
+			return nil, fmt.Errorf("invalid schedule %q, expected [days ]HH:MM-HH:MM=rate", item)
+		}
+
+		start, end, ok := strings.Cut(fields[0], "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid schedule time range %q", fields[0])
+		}
+		var err error
+		if schedule.Start, err = parseClock(start); err != nil {
+			return nil, err
+		}
+		if schedule.End, err = parseClock(end); err != nil {
+			return nil, err
+		}
+		if schedule.BytesPerSecond, err = ParseBandwidth(value); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+// parseWeekdays 解析 "mon-fri" 或 "sat,sun" 形式的星期列表
+func parseWeekdays(s string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, part := range strings.Split(strings.ToLower(s), ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		start, ok := weekdays[from]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q", from)
+		}
+		if !isRange {
+			days = append(days, start)
+			continue
+		}
+		end, ok := weekdays[to]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q", to)
+		}
+		for d := start; ; d = (d + 1) % 7 {
+			days = append(days, d)
+			if d == end {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseClock 解析 HH:MM，返回当天的分钟偏移，允许 24:00 表示一天结束
+func parseClock(s string) (int, error) {
+	hour, minute, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	h, err := strconv.Atoi(hour)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	m, err := strconv.Atoi(minute)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	if h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	return h*60 + m, nil
+}
+
+// limitUpstream 在回环地址上启动一个转发服务，经 limits 限速后转发到 proxyCfg.RemoteURL，
+// 并将 proxyCfg.RemoteURL 指向该服务。registry 的 proxy 固定使用 http.DefaultTransport 访问上游，
+// 没有替换 transport 的入口，通过转发只对上游流量限速，token 认证和其他客户端不受影响。
+// 上游返回的重定向（如 blob 的 CDN 地址）在转发服务内跟随，同样受限速
+func limitUpstream(proxyCfg *configuration.Proxy, limits Limits) (stop func() error, err error) {
+	target, err := url.Parse(proxyCfg.RemoteURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy remote url %q: %v", proxyCfg.RemoteURL, err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: NewLimitedTransport(http.DefaultTransport, limits)}
+	srv := &http.Server{Handler: &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+		},
+		Transport: redirectFollowingTransport{client: client},
+	}}
+	go srv.Serve(ln)
+	proxyCfg.RemoteURL = "http://" + ln.Addr().String()
+	return srv.Close, nil
+}
+
+// redirectFollowingTransport 用 http.Client 发送请求，在返回前跟随重定向
+type redirectFollowingTransport struct {
+	client *http.Client
+}
+
+func (t redirectFollowingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.RequestURI = ""
+	return t.client.Do(req)
+}
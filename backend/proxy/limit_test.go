@@ -0,0 +1,147 @@
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/distribution/distribution/v3/configuration"
+)
+
+func TestParseBandwidth(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"", 0},
+		{"0", 0},
+		{"512k", 512 * 1024},
+		{"10MB", 10 * 1024 * 1024},
+		{"10MB/s", 10 * 1024 * 1024},
+	}
+	for _, tt := range tests {
+		got, err := ParseBandwidth(tt.in)
+		if err != nil {
+			t.Fatalf("ParseBandwidth(%q): %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("ParseBandwidth(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+	if _, err := ParseBandwidth("fast"); err == nil {
+		t.Error("ParseBandwidth(\"fast\") succeeded, want error")
+	}
+}
+
+func TestParseBandwidthSchedules(t *testing.T) {
+	schedules, err := ParseBandwidthSchedules("mon-fri 09:00-18:00=2MB; sat,sun 22:00-06:00=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schedules) != 2 {
+		t.Fatalf("got %d schedules, want 2", len(schedules))
+	}
+
+	// 2026-10-19 是星期一
+	monday := func(hour int) time.Time { return time.Date(2026, 10, 19, hour, 0, 0, 0, time.Local) }
+	tests := []struct {
+		schedule int
+		at       time.Time
+		want     bool
+	}{
+		{0, monday(10), true},
+		{0, monday(18), false},
+		{0, monday(8), false},
+		{0, monday(10).AddDate(0, 0, 5), false}, // 星期六
+		{1, monday(3), true},                    // 星期日夜间延续到星期一凌晨
+		{1, monday(23), false},
+		{1, monday(23).AddDate(0, 0, 5), true},
+	}
+	for _, tt := range tests {
+		if got := schedules[tt.schedule].active(tt.at); got != tt.want {
+			t.Errorf("schedule %d active(%s) = %v, want %v", tt.schedule, tt.at.Format("Mon 15:04"), got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"09:00-18:00", "funday 09:00-18:00=1MB", "9-18=1MB", "25:00-26:00=1MB"} {
+		if _, err := ParseBandwidthSchedules(bad); err == nil {
+			t.Errorf("ParseBandwidthSchedules(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestLimitedTransportBandwidth(t *testing.T) {
+	upstream := newFakeUpstream(t, "", "")
+	layer := bytes.Repeat([]byte("x"), 3*limitChunkSize)
+	img := upstream.pushImage(t, "library/large", "latest", layer)
+
+	proxyCfg := configuration.Proxy{RemoteURL: upstream.URL}
+	stop, err := limitUpstream(&proxyCfg, Limits{BytesPerSecond: limitChunkSize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { stop() })
+	if _, ok := http.DefaultTransport.(*http.Transport); !ok {
+		t.Fatal("http.DefaultTransport was replaced")
+	}
+
+	proxy := newTestProxyFor(t, proxyCfg)
+	start := time.Now()
+	if got := getBlob(t, proxy.URL, img.repo, img.layer); !bytes.Equal(got, layer) {
+		t.Fatal("layer content mismatch")
+	}
+	// 令牌桶初始可突发一个分块，其余两个分块按每秒一个分块的速率读取
+	if elapsed := time.Since(start); elapsed < 1500*time.Millisecond {
+		t.Fatalf("blob pulled in %s, want throttled to at least 1.5s", elapsed)
+	}
+}
+
+func TestLimitedTransportMaxConnections(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		if r.URL.Path == "/slow" {
+			<-release
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewLimitedTransport(&http.Transport{}, Limits{MaxConnections: 1})}
+	slow, err := client.Get(srv.URL + "/slow")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		resp, err := client.Get(srv.URL + "/fast")
+		if err == nil {
+			resp.Body.Close()
+		}
+		done <- err
+	}()
+
+	// 第一个响应体未读完前，第二个请求必须排队
+	select {
+	case err := <-done:
+		t.Fatalf("second request finished while the only slot was held: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	close(release)
+	io.Copy(io.Discard, slow.Body)
+	slow.Body.Close()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued request did not proceed after the slot was released")
+	}
+}
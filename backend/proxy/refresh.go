@@ -0,0 +1,360 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// 每个 tag 保留的 digest 变更记录条数
+const maxDigestHistory = 50
+
+var manifestAccept = strings.Join([]string{
+	v1.MediaTypeImageIndex,
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	v1.MediaTypeImageManifest,
+	"application/vnd.docker.distribution.manifest.v2+json",
+}, ", ")
+
+// DigestChange 一次 tag 指向的 digest 变化
+type DigestChange struct {
+	Digest     string    `json:"digest"`
+	Previous   string    `json:"previous,omitempty"`
+	DetectedAt time.Time `json:"detectedAt"`
+	Prefetched bool      `json:"prefetched"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// WatchedTag 被监视的 tag 及其当前状态
+type WatchedTag struct {
+	Repository  string         `json:"repository"`
+	Tag         string         `json:"tag"`
+	Digest      string         `json:"digest"`
+	LastChecked time.Time      `json:"lastChecked"`
+	LastError   string         `json:"lastError,omitempty"`
+	History     []DigestChange `json:"history"`
+}
+
+// Name 返回 repository:tag 形式的名称
+func (w *WatchedTag) Name() string {
+	return w.Repository + ":" + w.Tag
+}
+
+// RefreshConfig tag 定时刷新配置
+type RefreshConfig struct {
+	// Tags 监视列表，格式为 repository:tag，如 library/nginx:stable
+	Tags []string
+	// Interval 检查间隔
+	Interval time.Duration
+	// Platforms 预取的平台，如 linux/amd64，为空时预取多架构镜像的全部平台
+	Platforms []string
+}
+
+// TagRefresher 定期通过代理自身检查上游 tag 的最新 digest，出现新 digest 时预取 manifest 和 blob 到缓存
+type TagRefresher struct {
+	baseURL  string
+	config   RefreshConfig
+	client   *http.Client
+	interval time.Duration
+
+	// refreshMu 保证同一时间只有一轮检查在进行
+	refreshMu sync.Mutex
+
+	mu   sync.RWMutex
+	tags []*WatchedTag
+}
+
+// NewTagRefresher 创建 tag 刷新器，baseURL 为代理自身的访问地址，如 http://127.0.0.1:5000
+func NewTagRefresher(baseURL string, config RefreshConfig) (*TagRefresher, error) {
+	r := &TagRefresher{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		config:   config,
+		client:   &http.Client{},
+		interval: config.Interval,
+	}
+	if r.interval <= 0 {
+		r.interval = 15 * time.Minute
+	}
+	for _, name := range config.Tags {
+		repo, tag, err := splitRepositoryTag(name)
+		if err != nil {
+			return nil, err
+		}
+		r.tags = append(r.tags, &WatchedTag{Repository: repo, Tag: tag})
+	}
+	return r, nil
+}
+
+// splitRepositoryTag 拆分 repository:tag，tag 缺省为 latest
+func splitRepositoryTag(name string) (string, string, error) {
+	name = strings.TrimSpace(name)
+	repo, tag := name, "latest"
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		repo, tag = name[:i], name[i+1:]
+	}
+	if repo == "" || tag == "" {
+		return "", "", fmt.Errorf("invalid watched tag %q, expected repository:tag", name)
+	}
+	return repo, tag, nil
+}
+
+// Run 立即检查一次，之后按间隔检查，直到 ctx 取消
+func (r *TagRefresher) Run(ctx context.Context) {
+	// 等待代理开始监听
+	for {
+		resp, err := r.do(ctx, http.MethodGet, "/v2/", "")
+		if err == nil {
+			resp.Body.Close()
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		r.RefreshAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RefreshAll 依次检查所有被监视的 tag
+func (r *TagRefresher) RefreshAll(ctx context.Context) {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+	for _, w := range r.tags {
+		if ctx.Err() != nil {
+			return
+		}
+		r.refresh(ctx, w)
+	}
+}
+
+func (r *TagRefresher) refresh(ctx context.Context, w *WatchedTag) {
+	log := logrus.WithField("tag", w.Name())
+
+	digest, err := r.headManifest(ctx, w.Repository, w.Tag)
+	r.mu.Lock()
+	w.LastChecked = time.Now()
+	if err != nil {
+		w.LastError = err.Error()
+		r.mu.Unlock()
+		log.WithError(err).Warn("failed to check upstream tag")
+		return
+	}
+	w.LastError = ""
+	previous := w.Digest
+	r.mu.Unlock()
+
+	if digest == previous {
+		return
+	}
+
+	log.WithFields(logrus.Fields{"digest": digest, "previous": previous}).Info("upstream tag digest changed, prefetching")
+	change := DigestChange{
+		Digest:     digest,
+		Previous:   previous,
+		DetectedAt: time.Now(),
+	}
+	if err := r.prefetch(ctx, w.Repository, digest); err != nil {
+		change.Error = err.Error()
+		log.WithError(err).Warn("failed to prefetch tag")
+	} else {
+		change.Prefetched = true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// 预取失败时保留旧 digest，下一轮会重试
+	if change.Prefetched {
+		w.Digest = digest
+	}
+	w.History = append(w.History, change)
+	if len(w.History) > maxDigestHistory {
+		w.History = w.History[len(w.History)-maxDigestHistory:]
+	}
+}
+
+// headManifest 查询 tag 当前指向的 digest，代理会先向上游确认
+func (r *TagRefresher) headManifest(ctx context.Context, repo, reference string) (string, error) {
+	resp, err := r.do(ctx, http.MethodHead, fmt.Sprintf("/v2/%s/manifests/%s", repo, reference), manifestAccept)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("missing Docker-Content-Digest header")
+	}
+	return digest, nil
+}
+
+// prefetch 拉取 manifest 及其引用的 blob，使其进入代理缓存
+func (r *TagRefresher) prefetch(ctx context.Context, repo, digest string) error {
+	resp, err := r.do(ctx, http.MethodGet, fmt.Sprintf("/v2/%s/manifests/%s", repo, digest), manifestAccept)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var m struct {
+		MediaType string          `json:"mediaType"`
+		Manifests []v1.Descriptor `json:"manifests"`
+		Config    v1.Descriptor   `json:"config"`
+		Layers    []v1.Descriptor `json:"layers"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		return fmt.Errorf("failed to decode manifest %s: %v", digest, err)
+	}
+
+	// 多架构镜像逐个预取所需平台
+	if len(m.Manifests) > 0 {
+		for _, desc := range m.Manifests {
+			if !r.wantPlatform(desc.Platform) {
+				continue
+			}
+			if err := r.prefetch(ctx, repo, desc.Digest.String()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	blobs := append([]v1.Descriptor{m.Config}, m.Layers...)
+	for _, desc := range blobs {
+		if desc.Digest == "" {
+			continue
+		}
+		if err := r.fetchBlob(ctx, repo, desc.Digest.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *TagRefresher) fetchBlob(ctx context.Context, repo, digest string) error {
+	resp, err := r.do(ctx, http.MethodGet, fmt.Sprintf("/v2/%s/blobs/%s", repo, digest), "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return fmt.Errorf("failed to fetch blob %s: %v", digest, err)
+	}
+	return nil
+}
+
+func (r *TagRefresher) wantPlatform(p *v1.Platform) bool {
+	if len(r.config.Platforms) == 0 {
+		return true
+	}
+	if p == nil {
+		return false
+	}
+	name := p.OS + "/" + p.Architecture
+	for _, want := range r.config.Platforms {
+		if want == name || (p.Variant != "" && want == name+"/"+p.Variant) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *TagRefresher) do(ctx context.Context, method, path, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, r.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: unexpected status %s", method, path, resp.Status)
+	}
+	return resp, nil
+}
+
+// Tags 返回所有被监视 tag 的状态快照
+func (r *TagRefresher) Tags() []WatchedTag {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]WatchedTag, 0, len(r.tags))
+	for _, w := range r.tags {
+		result = append(result, r.snapshot(w))
+	}
+	return result
+}
+
+// Tag 返回单个 tag 的状态快照
+func (r *TagRefresher) Tag(name string) (WatchedTag, bool) {
+	repo, tag, err := splitRepositoryTag(name)
+	if err != nil {
+		return WatchedTag{}, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, w := range r.tags {
+		if w.Repository == repo && w.Tag == tag {
+			return r.snapshot(w), true
+		}
+	}
+	return WatchedTag{}, false
+}
+
+func (r *TagRefresher) snapshot(w *WatchedTag) WatchedTag {
+	s := *w
+	s.History = append([]DigestChange{}, w.History...)
+	return s
+}
+
+// Handler 返回 tag 刷新的管理接口：
+//
+//	GET /admin/tags                       所有被监视的 tag 及其 digest 变更记录
+//	GET /admin/tags/history?tag=repo:tag  单个 tag 的 digest 变更记录
+//	POST /admin/tags/refresh              立即检查所有 tag
+func (r *TagRefresher) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/tags", func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusOK, r.Tags())
+	})
+	mux.HandleFunc("GET /admin/tags/history", func(w http.ResponseWriter, req *http.Request) {
+		tag, ok := r.Tag(req.URL.Query().Get("tag"))
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "tag is not watched"})
+			return
+		}
+		writeJSON(w, http.StatusOK, tag.History)
+	})
+	mux.HandleFunc("POST /admin/tags/refresh", func(w http.ResponseWriter, req *http.Request) {
+		go r.RefreshAll(context.WithoutCancel(req.Context()))
+		writeJSON(w, http.StatusAccepted, map[string]string{"message": "refresh started"})
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,97 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/distribution/distribution/v3/configuration"
+)
+
+func TestTagRefresherPrefetchesNewDigest(t *testing.T) {
+	upstream := newFakeUpstream(t, "", "")
+	first := upstream.pushImage(t, "library/nginx", "stable", []byte("nginx v1"))
+	proxy := newTestProxy(t, upstream, configuration.Proxy{})
+
+	refresher, err := NewTagRefresher(proxy.URL, RefreshConfig{Tags: []string{"library/nginx:stable"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refresher.RefreshAll(context.Background())
+	tag, ok := refresher.Tag("library/nginx:stable")
+	if !ok {
+		t.Fatal("watched tag not found")
+	}
+	if tag.Digest != first.manifest.String() {
+		t.Fatalf("digest = %s, want %s", tag.Digest, first.manifest)
+	}
+	if n := upstream.count(http.MethodGet, blobPath(first.repo, first.layer)); n != 1 {
+		t.Fatalf("upstream layer requests = %d, want 1 (prefetched)", n)
+	}
+
+	// digest 未变化时不会重复预取
+	refresher.RefreshAll(context.Background())
+	if tag, _ := refresher.Tag("library/nginx:stable"); len(tag.History) != 1 {
+		t.Fatalf("history length = %d, want 1", len(tag.History))
+	}
+
+	second := upstream.pushImage(t, "library/nginx", "stable", []byte("nginx v2"))
+	refresher.RefreshAll(context.Background())
+	tag, _ = refresher.Tag("library/nginx:stable")
+	if tag.Digest != second.manifest.String() {
+		t.Fatalf("digest after upstream update = %s, want %s", tag.Digest, second.manifest)
+	}
+	if len(tag.History) != 2 {
+		t.Fatalf("history length = %d, want 2", len(tag.History))
+	}
+	change := tag.History[1]
+	if !change.Prefetched || change.Previous != first.manifest.String() {
+		t.Fatalf("unexpected history entry: %+v", change)
+	}
+
+	// 预取后的镜像无需再回源
+	getBlob(t, proxy.URL, second.repo, second.layer)
+	if n := upstream.count(http.MethodGet, blobPath(second.repo, second.layer)); n != 1 {
+		t.Fatalf("upstream layer requests = %d, want 1", n)
+	}
+}
+
+func TestTagRefresherHandler(t *testing.T) {
+	upstream := newFakeUpstream(t, "", "")
+	img := upstream.pushImage(t, "library/alpine", "3.20", []byte("alpine"))
+	proxy := newTestProxy(t, upstream, configuration.Proxy{})
+
+	refresher, err := NewTagRefresher(proxy.URL, RefreshConfig{Tags: []string{"library/alpine:3.20"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	refresher.RefreshAll(context.Background())
+
+	admin := httptest.NewServer(refresher.Handler())
+	defer admin.Close()
+
+	resp, err := http.Get(admin.URL + "/admin/tags/history?tag=library/alpine:3.20")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var history []DigestChange
+	if err := json.NewDecoder(resp.Body).Decode(&history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Digest != img.manifest.String() {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+
+	resp, err = http.Get(admin.URL + "/admin/tags/history?tag=library/unknown:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("status for unwatched tag = %d, want 404", resp.StatusCode)
+	}
+}
@@ -8,8 +8,16 @@ import (
 	"github.com/distribution/distribution/v3/configuration"
 	"github.com/distribution/distribution/v3/registry"
 	_ "github.com/distribution/distribution/v3/registry/storage/driver/inmemory"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/smartcat999/container-ui/internal/logger"
 )
 
+// DefaultDrainTimeout 收到退出信号后等待进行中请求完成的默认时长
+const DefaultDrainTimeout = 10 * time.Second
+
 type registryTLSConfig struct {
 	cipherSuites    []string
 	certificatePath string
@@ -17,19 +25,90 @@ type registryTLSConfig struct {
 	certificate     *tls.Certificate
 }
 
-func SetUpRegistry(tlsCfg *registryTLSConfig, addr string, proxyCfg *configuration.Proxy) (*registry.Registry, error) {
+// Options 代理运行参数
+type Options struct {
+	// DrainTimeout 收到 SIGTERM/SIGINT 后停止接收新请求，并最多等待该时长让进行中的 blob 传输完成
+	DrainTimeout time.Duration
+	// LogLevel 日志级别：error/warn/info/debug，默认 info
+	LogLevel string
+	// LogFormatter 日志格式：json/text/logstash，默认 json
+	LogFormatter string
+	// Limits 上游带宽和并发连接限制，nil 表示不限制
+	Limits *Limits
+}
+
+// redactHook 在日志写出前屏蔽密码、令牌等敏感字段
+type redactHook struct{}
+
+func (redactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (redactHook) Fire(entry *logrus.Entry) error {
+	for key := range entry.Data {
+		if logger.IsSensitiveKey(key) {
+			entry.Data[key] = "[REDACTED]"
+		}
+	}
+	return nil
+}
+
+func init() {
+	logrus.AddHook(redactHook{})
+}
+
+func SetUpRegistry(tlsCfg *registryTLSConfig, addr string, proxyCfg *configuration.Proxy, opts *Options) (*registry.Registry, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if opts.Limits != nil {
+		// 修改副本，调用方的配置仍指向真实的上游。转发服务随进程退出
+		limited := *proxyCfg
+		if _, err := limitUpstream(&limited, *opts.Limits); err != nil {
+			return nil, err
+		}
+		proxyCfg = &limited
+	}
+	return registry.NewRegistry(context.Background(), newConfiguration(tlsCfg, addr, proxyCfg, opts))
+}
+
+// newConfiguration 生成代理的 registry 配置
+func newConfiguration(tlsCfg *registryTLSConfig, addr string, proxyCfg *configuration.Proxy, opts *Options) *configuration.Configuration {
 	config := &configuration.Configuration{}
-	// TODO: this needs to change to something ephemeral as the test will fail if there is any server
-	// already listening on port 5000
 	config.HTTP.Addr = addr
-	config.HTTP.DrainTimeout = time.Duration(10) * time.Second
+	// DrainTimeout 为 0 时 registry 不会处理退出信号，因此始终保证一个正值
+	config.HTTP.DrainTimeout = opts.DrainTimeout
+	if config.HTTP.DrainTimeout <= 0 {
+		config.HTTP.DrainTimeout = DefaultDrainTimeout
+	}
 	if tlsCfg != nil {
 		config.HTTP.TLS.CipherSuites = tlsCfg.cipherSuites
 		config.HTTP.TLS.Certificate = tlsCfg.certificatePath
 		config.HTTP.TLS.Key = tlsCfg.privateKeyPath
 	}
 	config.Proxy = *proxyCfg
-	config.Log.Level = "info"
-	config.Storage = map[string]configuration.Parameters{"inmemory": map[string]interface{}{}}
-	return registry.NewRegistry(context.Background(), config)
+	config.Log.Level = configuration.Loglevel(opts.LogLevel)
+	if config.Log.Level == "" {
+		config.Log.Level = "info"
+	}
+	config.Log.Formatter = opts.LogFormatter
+	if config.Log.Formatter == "" {
+		config.Log.Formatter = "json"
+	}
+	// 结构化输出时关闭 Apache 格式的访问日志，registry 会为每个请求输出带 http.request.id 的结构化日志
+	config.Log.AccessLog.Disabled = config.Log.Formatter != "text"
+	config.Storage = map[string]configuration.Parameters{
+		"inmemory": map[string]interface{}{},
+		// TTL 到期时调度器需要删除本地缓存的 blob 和 manifest，未开启删除时缓存永不过期
+		"delete": map[string]interface{}{"enabled": true},
+	}
+	return config
+}
+
+// ShutdownTelemetry 刷新并关闭 registry 注册的 trace 导出器，确保退出前缓冲的 span 全部写出
+func ShutdownTelemetry(ctx context.Context) error {
+	if tp, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider); ok {
+		return tp.Shutdown(ctx)
+	}
+	return nil
 }
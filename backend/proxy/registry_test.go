@@ -0,0 +1,114 @@
+package proxy
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/distribution/distribution/v3/configuration"
+)
+
+func TestPullThrough(t *testing.T) {
+	upstream := newFakeUpstream(t, "", "")
+	img := upstream.pushImage(t, "library/alpine", "3.20", []byte("alpine layer"))
+	proxy := newTestProxy(t, upstream, configuration.Proxy{})
+
+	if got := getManifest(t, proxy.URL, img.repo, img.tag); got != img.manifest {
+		t.Fatalf("manifest digest = %s, want %s", got, img.manifest)
+	}
+	if got := getBlob(t, proxy.URL, img.repo, img.layer); !bytes.Equal(got, img.content) {
+		t.Fatalf("layer content = %q, want %q", got, img.content)
+	}
+	if n := upstream.count(http.MethodGet, blobPath(img.repo, img.layer)); n != 1 {
+		t.Fatalf("upstream blob requests = %d, want 1", n)
+	}
+}
+
+func TestBlobIsCached(t *testing.T) {
+	upstream := newFakeUpstream(t, "", "")
+	img := upstream.pushImage(t, "library/busybox", "latest", []byte("busybox layer"))
+	proxy := newTestProxy(t, upstream, configuration.Proxy{})
+
+	for i := 0; i < 3; i++ {
+		getBlob(t, proxy.URL, img.repo, img.layer)
+	}
+	if n := upstream.count(http.MethodGet, blobPath(img.repo, img.layer)); n != 1 {
+		t.Fatalf("upstream blob requests = %d, want 1", n)
+	}
+}
+
+func TestManifestByDigestIsCached(t *testing.T) {
+	upstream := newFakeUpstream(t, "", "")
+	img := upstream.pushImage(t, "library/redis", "7", []byte("redis layer"))
+	proxy := newTestProxy(t, upstream, configuration.Proxy{})
+
+	for i := 0; i < 3; i++ {
+		getManifest(t, proxy.URL, img.repo, img.manifest.String())
+	}
+	path := "/v2/" + img.repo + "/manifests/" + img.manifest.String()
+	if n := upstream.count(http.MethodGet, path); n != 1 {
+		t.Fatalf("upstream manifest requests = %d, want 1", n)
+	}
+}
+
+func TestTagFollowsUpstream(t *testing.T) {
+	upstream := newFakeUpstream(t, "", "")
+	first := upstream.pushImage(t, "library/nginx", "stable", []byte("nginx v1"))
+	proxy := newTestProxy(t, upstream, configuration.Proxy{})
+
+	if got := getManifest(t, proxy.URL, first.repo, first.tag); got != first.manifest {
+		t.Fatalf("manifest digest = %s, want %s", got, first.manifest)
+	}
+
+	second := upstream.pushImage(t, "library/nginx", "stable", []byte("nginx v2"))
+	if got := getManifest(t, proxy.URL, second.repo, second.tag); got != second.manifest {
+		t.Fatalf("manifest digest after upstream update = %s, want %s", got, second.manifest)
+	}
+}
+
+func TestBlobExpiresAfterTTL(t *testing.T) {
+	upstream := newFakeUpstream(t, "", "")
+	img := upstream.pushImage(t, "library/postgres", "16", []byte("postgres layer"))
+	ttl := 500 * time.Millisecond
+	proxy := newTestProxy(t, upstream, configuration.Proxy{TTL: &ttl})
+
+	getBlob(t, proxy.URL, img.repo, img.layer)
+	getBlob(t, proxy.URL, img.repo, img.layer)
+	if n := upstream.count(http.MethodGet, blobPath(img.repo, img.layer)); n != 1 {
+		t.Fatalf("upstream blob requests before expiry = %d, want 1", n)
+	}
+
+	// 过期后缓存被清理，再次拉取会回源
+	eventually(t, 5*time.Second, func() bool {
+		getBlob(t, proxy.URL, img.repo, img.layer)
+		return upstream.count(http.MethodGet, blobPath(img.repo, img.layer)) > 1
+	}, "cached blob was not evicted after TTL")
+}
+
+func TestUpstreamBasicAuth(t *testing.T) {
+	upstream := newFakeUpstream(t, "agent", "s3cret")
+	img := upstream.pushImage(t, "team/app", "v1", []byte("private layer"))
+
+	proxy := newTestProxy(t, upstream, configuration.Proxy{Username: "agent", Password: "s3cret"})
+	if got := getBlob(t, proxy.URL, img.repo, img.layer); !bytes.Equal(got, img.content) {
+		t.Fatalf("layer content = %q, want %q", got, img.content)
+	}
+}
+
+func TestUpstreamBasicAuthRejected(t *testing.T) {
+	upstream := newFakeUpstream(t, "agent", "s3cret")
+	img := upstream.pushImage(t, "team/app", "v1", []byte("private layer"))
+
+	proxy := newTestProxy(t, upstream, configuration.Proxy{Username: "agent", Password: "wrong"})
+	_, status, err := tryGetBlob(proxy.URL, img.repo, img.layer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status == http.StatusOK {
+		t.Fatal("blob was served with invalid upstream credentials")
+	}
+	if n := upstream.count(http.MethodGet, blobPath(img.repo, img.layer)); n != 0 {
+		t.Fatalf("upstream served %d authenticated blob requests, want 0", n)
+	}
+}